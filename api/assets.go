@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+var assetHTTPClient = &http.Client{}
+
+// Asset is a downloaded change asset together with the validators needed to
+// revalidate it later.
+type Asset struct {
+	Data         []byte
+	ETag         string
+	LastModified string
+	// NotModified is set when a conditional request found the cached copy
+	// still current. Data is empty in that case.
+	NotModified bool
+}
+
+// AssetValidators identify a previously downloaded copy of an asset.
+type AssetValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchAsset downloads a change asset (HTML snapshot, diff JSON, screenshot)
+// from the URL stored on a ChangeEvent. Asset URLs are not signed.
+func FetchAsset(ctx context.Context, url string) ([]byte, error) {
+	a, err := FetchAssetIfModified(ctx, url, AssetValidators{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return a.Data, nil
+}
+
+// FetchAssetIfModified downloads an asset unless the server confirms that the
+// copy described by v is still current. progress, if not nil, is called as the
+// body is read; total is -1 when the server did not send a Content-Length.
+func FetchAssetIfModified(ctx context.Context, url string, v AssetValidators, progress func(read, total int64)) (*Asset, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+	resp, err := assetHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	a := &Asset{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		a.NotModified = true
+		return a, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, ErrorFromResponse(resp)
+	}
+
+	a.Data, err = readBody(resp, progress)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func readBody(resp *http.Response, progress func(read, total int64)) ([]byte, error) {
+	if progress == nil {
+		return io.ReadAll(resp.Body)
+	}
+
+	var buf bytes.Buffer
+	if resp.ContentLength > 0 {
+		buf.Grow(int(resp.ContentLength))
+	}
+	progress(0, resp.ContentLength)
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(chunk)
+		buf.Write(chunk[:n])
+		if n > 0 {
+			progress(int64(buf.Len()), resp.ContentLength)
+		}
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
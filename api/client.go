@@ -2,17 +2,39 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// DefaultTimeout bounds every API call that is not given a deadline by its caller.
+const DefaultTimeout = 30 * time.Second
+
 type Client struct {
-	BaseURL        string
+	BaseURL string
+	// InstanceKey and InstanceSecret are the initial credentials; once the
+	// client is in use, change them with SetCredentials.
 	InstanceKey    string
 	InstanceSecret string
+	// Timeout is applied to each call on top of the caller's context.
+	// Zero disables the per-call deadline.
+	Timeout time.Duration
+	// Retry decides how transient failures are retried.
+	Retry RetryPolicy
+	// OnUnauthorized, if set, is called whenever the backend rejects the
+	// instance credentials with 401. It may be called from any goroutine.
+	OnUnauthorized func(err *APIError)
 	httpClient     *http.Client
+
+	mu sync.RWMutex
 }
 
 type registerInstanceReq struct {
@@ -25,21 +47,32 @@ type registerInstanceResp struct {
 }
 
 func RegisterInstance(baseURL, name string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return RegisterInstanceContext(ctx, baseURL, name)
+}
+
+func RegisterInstanceContext(ctx context.Context, baseURL, name string) (string, string, error) {
 	body := registerInstanceReq{Name: name}
 	b, err := json.Marshal(body)
 	if err != nil {
 		return "", "", err
 	}
 
-	resp, err := http.Post(baseURL+"/api/instances/register", "application/json", bytes.NewReader(b))
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/instances/register", bytes.NewReader(b))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		data, _ := io.ReadAll(resp.Body)
-		return "", "", fmt.Errorf("http %d: %s", resp.StatusCode, string(data))
+		return "", "", ErrorFromResponse(resp)
 	}
 
 	var out registerInstanceResp
@@ -49,46 +82,155 @@ func RegisterInstance(baseURL, name string) (string, string, error) {
 	return out.InstanceKey, out.InstanceSecret, nil
 }
 
+// CheckBackend verifies that a Watcher backend answers at baseURL. Any
+// response below 500 counts as reachable, since the health endpoint may be
+// absent on older backends.
+func CheckBackend(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+"/api/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return ErrorFromResponse(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
 func NewClient(baseURL, key, secret string) *Client {
 	return &Client{
 		BaseURL:        baseURL,
 		InstanceKey:    key,
 		InstanceSecret: secret,
+		Timeout:        DefaultTimeout,
+		Retry:          DefaultRetryPolicy,
 		httpClient:     &http.Client{},
 	}
 }
 
-func (c *Client) do(method, path string, body any, out any) error {
-	var buf io.Reader
+// Credentials returns the instance key and secret currently used for signing.
+func (c *Client) Credentials() (string, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.InstanceKey, c.InstanceSecret
+}
+
+// SetCredentials replaces the instance credentials for subsequent calls.
+func (c *Client) SetCredentials(key, secret string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.InstanceKey = key
+	c.InstanceSecret = secret
+}
+
+// RotateSecret asks the backend for a fresh instance key/secret pair, signed
+// with the current credentials. The old secret stops working as soon as the
+// backend answers, so callers must persist the returned pair and then switch
+// the client over with SetCredentials. It is never retried: a retry after a
+// lost response would be signed with the secret that was just invalidated.
+func (c *Client) RotateSecret(ctx context.Context) (string, string, error) {
+	var out registerInstanceResp
+	err := c.do(ctx, "POST", "/api/instances/rotate", nil, &out)
+	if err != nil {
+		return "", "", err
+	}
+	if out.InstanceSecret == "" {
+		return "", "", errors.New("rotate: backend returned no secret")
+	}
+	if out.InstanceKey == "" {
+		out.InstanceKey, _ = c.Credentials()
+	}
+	return out.InstanceKey, out.InstanceSecret, nil
+}
+
+// requestOption adjusts an outgoing request before it is sent.
+type requestOption func(*http.Request)
+
+func withIdempotencyKey(key string) requestOption {
+	return func(r *http.Request) {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body any, out any, opts ...requestOption) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var payload []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return err
 		}
-		buf = bytes.NewReader(b)
+		payload = b
 	}
 
-	req, err := http.NewRequest(method, c.BaseURL+path, buf)
-	if err != nil {
-		return err
-	}
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	if c.InstanceKey != "" {
-		req.Header.Set("X-Instance-Key", c.InstanceKey)
-		req.Header.Set("X-Instance-Secret", c.InstanceSecret)
-	}
+	for attempt := 1; ; attempt++ {
+		var buf io.Reader
+		if payload != nil {
+			buf = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, buf)
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for _, opt := range opts {
+			opt(req)
+		}
+		key, secret := c.Credentials()
+		if key != "" {
+			// Signed per attempt so every retry carries a fresh nonce.
+			SignRequest(req, key, secret, payload, time.Now())
+		}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+		last := attempt >= c.Retry.attempts() || !canRetry(req)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if last || !transientNetError(err) {
+				return err
+			}
+			if err := sleepContext(ctx, c.Retry.delay(attempt, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !last && retryableStatus(resp.StatusCode) {
+			wait := c.Retry.delay(attempt, resp)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err := sleepContext(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = decodeResponse(resp, out)
+		var apiErr *APIError
+		if key != "" && c.OnUnauthorized != nil && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.OnUnauthorized(apiErr)
+		}
 		return err
 	}
+}
+
+func decodeResponse(resp *http.Response, out any) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("http %d: %s", resp.StatusCode, string(b))
+		return ErrorFromResponse(resp)
 	}
 
 	if out != nil {
@@ -98,45 +240,129 @@ func (c *Client) do(method, path string, body any, out any) error {
 }
 
 func (c *Client) ListMonitors() ([]Monitor, error) {
+	return c.ListMonitorsContext(context.Background())
+}
+
+func (c *Client) ListMonitorsContext(ctx context.Context) ([]Monitor, error) {
 	var ms []Monitor
-	err := c.do("GET", "/api/monitors", nil, &ms)
+	err := c.do(ctx, "GET", "/api/monitors", nil, &ms)
 	return ms, err
 }
 
 type CreateMonitorReq struct {
-	Name             string  `json:"name"`
-	URL              string  `json:"url"`
-	CSSSelector      *string `json:"css_selector,omitempty"`
-	FrequencySeconds int     `json:"frequency_seconds"`
-	NotifyEmail      bool    `json:"notify_email"`
-	NotifyEmailAddr  string  `json:"notify_email_address"`
+	Name             string   `json:"name"`
+	URL              string   `json:"url"`
+	CSSSelector      *string  `json:"css_selector,omitempty"`
+	FrequencySeconds int      `json:"frequency_seconds"`
+	NotifyEmail      bool     `json:"notify_email"`
+	NotifyEmailAddr  string   `json:"notify_email_address"`
+	TagIDs           []uint64 `json:"tag_ids,omitempty"`
 }
 
+// UpdateMonitorReq is a partial update: nil fields are left unchanged.
+// CSSSelector and NotifyEmailAddr may point to "" to clear them, and TagIDs
+// to an empty list to remove all tags.
 type UpdateMonitorReq struct {
-	FrequencySeconds int  `json:"frequency_seconds"`
-	Active           bool `json:"active"`
+	Name             *string   `json:"name,omitempty"`
+	URL              *string   `json:"url,omitempty"`
+	CSSSelector      *string   `json:"css_selector,omitempty"`
+	FrequencySeconds *int      `json:"frequency_seconds,omitempty"`
+	NotifyEmail      *bool     `json:"notify_email,omitempty"`
+	NotifyEmailAddr  *string   `json:"notify_email_address,omitempty"`
+	Active           *bool     `json:"active,omitempty"`
+	TagIDs           *[]uint64 `json:"tag_ids,omitempty"`
+}
+
+// IsEmpty reports whether the request would not change anything.
+func (r UpdateMonitorReq) IsEmpty() bool {
+	return r == UpdateMonitorReq{}
 }
 
 func (c *Client) CreateMonitor(req CreateMonitorReq) (*Monitor, error) {
+	return c.CreateMonitorContext(context.Background(), req)
+}
+
+func (c *Client) CreateMonitorContext(ctx context.Context, req CreateMonitorReq) (*Monitor, error) {
+	// A single key for all attempts lets the backend drop duplicate creates.
+	return c.CreateMonitorOnce(ctx, NewIdempotencyKey(), req)
+}
+
+// CreateMonitorOnce creates a monitor under a caller-chosen idempotency key,
+// so a create that is sent again later, e.g. replayed from an offline queue
+// after a crash, is only applied once.
+func (c *Client) CreateMonitorOnce(ctx context.Context, key string, req CreateMonitorReq) (*Monitor, error) {
 	var m Monitor
-	err := c.do("POST", "/api/monitors", req, &m)
+	err := c.do(ctx, "POST", "/api/monitors", req, &m, withIdempotencyKey(key))
 	return &m, err
 }
 
 func (c *Client) UpdateMonitor(id uint64, req UpdateMonitorReq) (*Monitor, error) {
+	return c.UpdateMonitorContext(context.Background(), id, req)
+}
+
+func (c *Client) UpdateMonitorContext(ctx context.Context, id uint64, req UpdateMonitorReq) (*Monitor, error) {
 	var m Monitor
-	path := fmt.Sprintf("/api/monitors/%d", id)
-	err := c.do("PUT", path, req, &m)
+	err := c.do(ctx, "PATCH", fmt.Sprintf("/api/monitors/%d", id), req, &m)
 	return &m, err
 }
 
 func (c *Client) DeleteMonitor(id uint64) error {
-	return c.do("DELETE", fmt.Sprintf("/api/monitors/%d", id), nil, nil)
+	return c.DeleteMonitorContext(context.Background(), id)
+}
+
+func (c *Client) DeleteMonitorContext(ctx context.Context, id uint64) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/api/monitors/%d", id), nil, nil)
+}
+
+// DefaultChangesPageSize is used when ChangesQuery.Limit is zero.
+const DefaultChangesPageSize = 50
+
+// ChangesQuery selects a page of change events. Cursor comes from a previous
+// ChangesPage.NextCursor; Before and After bound CreatedAt when non-zero.
+type ChangesQuery struct {
+	Cursor string
+	Limit  int
+	Before time.Time
+	After  time.Time
+}
+
+func (q ChangesQuery) encode() string {
+	v := url.Values{}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultChangesPageSize
+	}
+	v.Set("limit", strconv.Itoa(limit))
+	if q.Cursor != "" {
+		v.Set("cursor", q.Cursor)
+	}
+	if !q.Before.IsZero() {
+		v.Set("before", q.Before.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.After.IsZero() {
+		v.Set("after", q.After.UTC().Format(time.RFC3339Nano))
+	}
+	return v.Encode()
 }
 
+// ListChanges returns the most recent page of changes for a monitor.
 func (c *Client) ListChanges(monitorID uint64) ([]ChangeEvent, error) {
-	var out []ChangeEvent
-	path := fmt.Sprintf("/api/monitors/%d/changes?limit=50", monitorID)
-	err := c.do("GET", path, nil, &out)
-	return out, err
+	return c.ListChangesContext(context.Background(), monitorID)
+}
+
+func (c *Client) ListChangesContext(ctx context.Context, monitorID uint64) ([]ChangeEvent, error) {
+	page, err := c.ListChangesPage(ctx, monitorID, ChangesQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func (c *Client) ListChangesPage(ctx context.Context, monitorID uint64, q ChangesQuery) (*ChangesPage, error) {
+	var page ChangesPage
+	path := fmt.Sprintf("/api/monitors/%d/changes?%s", monitorID, q.encode())
+	if err := c.do(ctx, "GET", path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
 }
@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+// seedChanges adds n changes a minute apart to a new monitor and returns the
+// monitor and the change IDs, newest first.
+func seedChanges(srv *fake.Server, key string, n int) (api.Monitor, []uint64) {
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := make([]uint64, n)
+	for i := range n {
+		c := srv.AddChange(api.ChangeEvent{MonitorID: m.ID, CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+		ids[n-1-i] = c.ID
+	}
+	return m, ids
+}
+
+func TestListChangesPagesThroughCursors(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m, want := seedChanges(srv, key, 7)
+	c := api.NewClient(srv.URL, key, secret)
+
+	var got []uint64
+	var pages int
+	q := api.ChangesQuery{Limit: 3}
+	for {
+		page, err := c.ListChangesPage(context.Background(), m.ID, q)
+		if err != nil {
+			t.Fatalf("ListChangesPage(%+v): %v", q, err)
+		}
+		pages++
+		if page.Total != len(want) {
+			t.Errorf("page %d: Total = %d, want %d", pages, page.Total, len(want))
+		}
+		for _, ch := range page.Items {
+			got = append(got, ch.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if pages > len(want) {
+			t.Fatal("cursor never ran out")
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	if pages != 3 {
+		t.Errorf("read %d pages, want 3", pages)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got changes %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got changes %v, want %v (newest first)", got, want)
+		}
+	}
+}
+
+func TestListChangesTimeBounds(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m, ids := seedChanges(srv, key, 5)
+	c := api.NewClient(srv.URL, key, secret)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Changes are at start+0..4 minutes; both bounds are exclusive.
+	page, err := c.ListChangesPage(context.Background(), m.ID, api.ChangesQuery{
+		After:  start.Add(time.Minute),
+		Before: start.Add(4 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("ListChangesPage: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != ids[1] || page.Items[1].ID != ids[2] {
+		t.Fatalf("got %d changes %+v, want changes %d and %d", len(page.Items), page.Items, ids[1], ids[2])
+	}
+	if page.Total != 2 || page.NextCursor != "" {
+		t.Errorf("Total = %d, NextCursor = %q; want 2 and none", page.Total, page.NextCursor)
+	}
+}
+
+func TestListChangesOfOtherInstanceIsNotFound(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	owner, _ := srv.RegisterInstance("owner")
+	m, _ := seedChanges(srv, owner, 1)
+	key, secret := srv.RegisterInstance("other")
+
+	_, err := api.NewClient(srv.URL, key, secret).ListChangesPage(context.Background(), m.ID, api.ChangesQuery{})
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("ListChangesPage error = %v, want %v", err, api.ErrNotFound)
+	}
+}
+
+func TestListChangesRejectsBadCursor(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m, _ := seedChanges(srv, key, 1)
+
+	_, err := api.NewClient(srv.URL, key, secret).ListChangesPage(context.Background(), m.ID, api.ChangesQuery{Cursor: "nope"})
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Fatalf("ListChangesPage error = %v, want 400", err)
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import "encoding/json"
+
+// Kinds of DiffSegment; plain text has an empty Kind.
+const (
+	DiffInserted = "inserted"
+	DiffDeleted  = "deleted"
+	DiffReplaced = "replaced"
+)
+
+// DiffSegment is one run of text in the diff JSON referenced by
+// ChangeEvent.HTMLDiff.
+type DiffSegment struct {
+	Text string `json:"text"`
+	Kind string `json:"kind,omitempty"`
+}
+
+func DecodeDiff(data []byte) ([]DiffSegment, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var segments []DiffSegment
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnavailable  = errors.New("backend unavailable")
+)
+
+// FieldError is a validation failure reported for a single request field,
+// named by its JSON key (e.g. "url", "frequency_seconds").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is returned for every non-2xx response. Use errors.As to inspect
+// it, or errors.Is against ErrUnauthorized, ErrNotFound, ErrConflict,
+// ErrValidation and ErrUnavailable.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     []FieldError
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "http %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, " %s", e.Code)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "; %s: %s", f.Field, f.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity || len(e.Fields) > 0
+	case ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// IsUnreachable reports whether err means the backend could not be reached
+// at all: a transient network failure, a timeout, or a gateway in front of it
+// answering 502, 503 or 504. Cancelled requests, TLS and certificate errors,
+// unknown hosts and malformed URLs do not count.
+func IsUnreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, context.DeadlineExceeded) || transientNetError(err)
+}
+
+// FieldError returns the message reported for field, or "" if there is none.
+func (e *APIError) FieldError(field string) string {
+	for _, f := range e.Fields {
+		if f.Field == field {
+			return f.Message
+		}
+	}
+	return ""
+}
+
+// errorBody covers the shapes the backend uses for error responses:
+// {"error": "msg"}, {"error": {...}} and a flat {"code", "message", "fields"}.
+type errorBody struct {
+	Error     json.RawMessage `json:"error"`
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Fields    json.RawMessage `json:"fields"`
+	RequestID string          `json:"request_id"`
+}
+
+// ErrorFromResponse builds an APIError from a failed response, consuming its body.
+func ErrorFromResponse(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var body errorBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		e.Message = strings.TrimSpace(string(data))
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+
+	if len(body.Error) > 0 {
+		var msg string
+		if json.Unmarshal(body.Error, &msg) == nil {
+			e.Message = msg
+		} else {
+			var nested errorBody
+			if json.Unmarshal(body.Error, &nested) == nil {
+				body.Code = firstNonEmpty(body.Code, nested.Code)
+				body.Message = firstNonEmpty(body.Message, nested.Message)
+				body.RequestID = firstNonEmpty(body.RequestID, nested.RequestID)
+				if len(body.Fields) == 0 {
+					body.Fields = nested.Fields
+				}
+			}
+		}
+	}
+	e.Code = body.Code
+	e.Message = firstNonEmpty(body.Message, e.Message)
+	e.RequestID = firstNonEmpty(body.RequestID, e.RequestID)
+	e.Fields = decodeFieldErrors(body.Fields)
+	return e
+}
+
+// decodeFieldErrors accepts either a list of FieldError or a field→message map.
+func decodeFieldErrors(raw json.RawMessage) []FieldError {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []FieldError
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var byName map[string]string
+	if json.Unmarshal(raw, &byName) != nil {
+		return nil
+	}
+	for field, msg := range byName {
+		list = append(list, FieldError{Field: field, Message: msg})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Field < list[j].Field })
+	return list
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
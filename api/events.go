@@ -0,0 +1,244 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types sent on the backend event stream.
+const (
+	EventMonitorCreated = "monitor.created"
+	EventMonitorUpdated = "monitor.updated"
+	EventMonitorDeleted = "monitor.deleted"
+	EventRunCompleted   = "run.completed"
+	EventChangeDetected = "change.detected"
+	EventTagCreated     = "tag.created"
+	EventTagUpdated     = "tag.updated"
+	// EventTagDeleted only carries the tag's ID. The backend also sends
+	// monitor.updated for each monitor the tag was removed from.
+	EventTagDeleted = "tag.deleted"
+	// EventResync means events were missed, e.g. because the backend no
+	// longer has the one to resume after. Reload everything.
+	EventResync = "resync"
+)
+
+// Event is one message from the backend event stream. Depending on Type,
+// Monitor, Run, Change or Tag is set; MonitorID is set for all but tag
+// events and EventResync.
+type Event struct {
+	ID        string
+	Type      string
+	MonitorID uint64
+	Monitor   *Monitor
+	Run       *RunResult
+	Change    *ChangeEvent
+	Tag       *Tag
+}
+
+// RunResult reports a finished check of a monitor.
+type RunResult struct {
+	MonitorID uint64    `json:"monitor_id"`
+	RunID     uint64    `json:"run_id"`
+	Status    string    `json:"status"`
+	Changed   bool      `json:"changed"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// EventsRetryPolicy paces reconnects of the event stream. Only BaseDelay,
+// MaxDelay and Jitter are used; Subscribe retries until cancelled.
+var EventsRetryPolicy = RetryPolicy{
+	BaseDelay: time.Second,
+	MaxDelay:  30 * time.Second,
+	Jitter:    0.5,
+}
+
+// EventsIdleTimeout is how long the event stream may stay silent before
+// Subscribe gives up on the connection and opens a new one. The backend
+// sends a keep-alive comment every 15 seconds, so only a dead connection,
+// e.g. one cut by a NAT timeout or a suspended laptop, stays quiet this long.
+var EventsIdleTimeout = 45 * time.Second
+
+// ErrStreamIdle is reported to onState when the event stream was dropped
+// for staying silent longer than EventsIdleTimeout.
+var ErrStreamIdle = errors.New("event stream went quiet")
+
+// Subscribe follows the backend event stream at /api/events and calls
+// onEvent for each event until ctx is cancelled. Dropped connections are
+// reopened with backoff, resuming after the last event received (starting
+// after lastEventID, if not empty). onState, if not nil, is called whenever
+// the stream connects or drops. Both callbacks run on Subscribe's goroutine.
+//
+// Subscribe only returns once ctx is cancelled, or for errors that retrying
+// cannot fix: rejected credentials, or a backend without an event stream
+// (ErrNotFound).
+func (c *Client) Subscribe(ctx context.Context, lastEventID string, onEvent func(Event), onState func(connected bool, err error)) error {
+	if onState == nil {
+		onState = func(bool, error) {}
+	}
+	attempt := 0
+	for {
+		var retry time.Duration
+		connected, err := c.streamEvents(ctx, &lastEventID, &retry, onEvent, func() {
+			attempt = 0
+			onState(true, nil)
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected || err != nil {
+			onState(false, err)
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized ||
+			apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound) {
+			return err
+		}
+
+		attempt++
+		wait := retry
+		if wait <= 0 {
+			wait = EventsRetryPolicy.backoff(attempt)
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// streamEvents reads one connection of the event stream until it ends or
+// stays silent for EventsIdleTimeout. lastEventID and retry are updated from
+// the stream as it is read.
+func (c *Client) streamEvents(ctx context.Context, lastEventID *string, retry *time.Duration, onEvent func(Event), onConnect func()) (connected bool, err error) {
+	// A half-open connection never ends a read, so a watchdog cancels the
+	// request when no line arrives in time.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(EventsIdleTimeout, func() { cancel(ErrStreamIdle) })
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/api/events", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		req.Header.Set("Last-Event-ID", *lastEventID)
+	}
+	key, secret := c.Credentials()
+	if key != "" {
+		SignRequest(req, key, secret, nil, time.Now())
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, ErrStreamIdle) {
+			err = cause
+		}
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		err := ErrorFromResponse(resp)
+		var apiErr *APIError
+		if key != "" && c.OnUnauthorized != nil && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.OnUnauthorized(apiErr)
+		}
+		return false, err
+	}
+	onConnect()
+
+	r := bufio.NewReader(resp.Body)
+	var (
+		id, typ string
+		hasID   bool
+		data    strings.Builder
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, ErrStreamIdle) {
+				err = cause
+			} else if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return true, err
+		}
+		idle.Reset(EventsIdleTimeout)
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// A blank line dispatches the event collected so far.
+			if hasID {
+				*lastEventID = id
+			}
+			if data.Len() > 0 {
+				if ev, ok := decodeEvent(*lastEventID, typ, data.String()); ok {
+					onEvent(ev)
+				}
+			}
+			id, typ, hasID = "", "", false
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment, used as keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id, hasID = value, true
+		case "event":
+			typ = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				*retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// decodeEvent parses the data of an event. Events of unknown types are
+// passed on with only ID and Type set; malformed ones are dropped.
+func decodeEvent(id, typ, data string) (Event, bool) {
+	ev := Event{ID: id, Type: typ}
+	var err error
+	switch typ {
+	case EventMonitorCreated, EventMonitorUpdated:
+		ev.Monitor = &Monitor{}
+		err = json.Unmarshal([]byte(data), ev.Monitor)
+		ev.MonitorID = ev.Monitor.ID
+	case EventMonitorDeleted:
+		var d struct {
+			ID uint64 `json:"id"`
+		}
+		err = json.Unmarshal([]byte(data), &d)
+		ev.MonitorID = d.ID
+	case EventRunCompleted:
+		ev.Run = &RunResult{}
+		err = json.Unmarshal([]byte(data), ev.Run)
+		ev.MonitorID = ev.Run.MonitorID
+	case EventChangeDetected:
+		ev.Change = &ChangeEvent{}
+		err = json.Unmarshal([]byte(data), ev.Change)
+		ev.MonitorID = ev.Change.MonitorID
+	case EventTagCreated, EventTagUpdated, EventTagDeleted:
+		// A deleted tag is reported as {"id": ...}, which decodes the same.
+		ev.Tag = &Tag{}
+		err = json.Unmarshal([]byte(data), ev.Tag)
+	}
+	return ev, err == nil
+}
@@ -0,0 +1,206 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+// subscription follows the event stream of c in the background.
+type subscription struct {
+	events    chan api.Event
+	connected chan bool
+	done      chan error
+	cancel    context.CancelFunc
+}
+
+func subscribe(t *testing.T, c *api.Client, lastEventID string) *subscription {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &subscription{
+		events:    make(chan api.Event, 100),
+		connected: make(chan bool, 100),
+		done:      make(chan error, 1),
+		cancel:    cancel,
+	}
+	go func() {
+		s.done <- c.Subscribe(ctx, lastEventID,
+			func(ev api.Event) { s.events <- ev },
+			func(connected bool, _ error) { s.connected <- connected })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-s.done
+	})
+	return s
+}
+
+func (s *subscription) waitConnected(t *testing.T) {
+	t.Helper()
+	select {
+	case <-s.connected:
+	case err := <-s.done:
+		t.Fatalf("Subscribe returned before connecting: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not connect")
+	}
+}
+
+func (s *subscription) next(t *testing.T) api.Event {
+	t.Helper()
+	select {
+	case ev := <-s.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return api.Event{}
+	}
+}
+
+func TestSubscribeStreamsEvents(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	other, _ := srv.RegisterInstance("other")
+	sub := subscribe(t, api.NewClient(srv.URL, key, secret), "")
+	sub.waitConnected(t)
+
+	srv.AddMonitor(other, api.Monitor{Name: "Not ours", URL: "https://example.org", FrequencySeconds: 60})
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	srv.CompleteRun(m.ID, "ok", true)
+	change := srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+
+	ev := sub.next(t)
+	if ev.Type != api.EventMonitorCreated || ev.Monitor == nil || ev.Monitor.Name != "Example" || ev.MonitorID != m.ID {
+		t.Fatalf("first event = %+v, want monitor.created for our monitor only", ev)
+	}
+	ev = sub.next(t)
+	if ev.Type != api.EventRunCompleted || ev.Run == nil || ev.Run.Status != "ok" || ev.MonitorID != m.ID {
+		t.Fatalf("second event = %+v, want run.completed", ev)
+	}
+	ev = sub.next(t)
+	if ev.Type != api.EventChangeDetected || ev.Change == nil || ev.Change.ID != change.ID {
+		t.Fatalf("third event = %+v, want change.detected for change %d", ev, change.ID)
+	}
+	if ev.ID == "" {
+		t.Error("event has no ID to resume after")
+	}
+}
+
+func TestSubscribeResumesAfterLastEventID(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	c := api.NewClient(srv.URL, key, secret)
+
+	first := subscribe(t, c, "")
+	first.waitConnected(t)
+	a := srv.AddMonitor(key, api.Monitor{Name: "A", URL: "https://a.example.com", FrequencySeconds: 60})
+	seen := first.next(t)
+	first.cancel()
+
+	// Published while nobody was listening.
+	b := srv.AddMonitor(key, api.Monitor{Name: "B", URL: "https://b.example.com", FrequencySeconds: 60})
+
+	resumed := subscribe(t, c, seen.ID)
+	if ev := resumed.next(t); ev.Type != api.EventMonitorCreated || ev.MonitorID != b.ID {
+		t.Fatalf("resumed stream started with %+v, want monitor.created for %d (not %d again)", ev, b.ID, a.ID)
+	}
+}
+
+func TestSubscribeAsksToResyncAfterUnknownEventID(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+
+	sub := subscribe(t, api.NewClient(srv.URL, key, secret), "999999")
+	if ev := sub.next(t); ev.Type != api.EventResync {
+		t.Fatalf("first event = %+v, want %s", ev, api.EventResync)
+	}
+}
+
+func TestSubscribeStopsOnRejectedCredentials(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, _ := srv.RegisterInstance("test")
+
+	err := api.NewClient(srv.URL, key, "wrong").Subscribe(context.Background(), "", func(api.Event) {}, nil)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("Subscribe error = %v, want 401", err)
+	}
+}
+
+func TestSubscribeReturnsWhenCancelled(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+
+	sub := subscribe(t, api.NewClient(srv.URL, key, secret), "")
+	sub.waitConnected(t)
+	sub.cancel()
+	select {
+	case err := <-sub.done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Subscribe error = %v, want %v", err, context.Canceled)
+		}
+		sub.done <- err // for the cleanup
+	case <-time.After(5 * time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
+
+func TestSubscribeReconnectsWhenStreamGoesQuiet(t *testing.T) {
+	defer func(d time.Duration) { api.EventsIdleTimeout = d }(api.EventsIdleTimeout)
+	api.EventsIdleTimeout = 100 * time.Millisecond
+
+	// The server answers and then goes silent, like a connection that died
+	// without being closed.
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 10\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dropped := make(chan error, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- api.NewClient(srv.URL, "", "").Subscribe(ctx, "", func(api.Event) {}, func(connected bool, err error) {
+			if !connected {
+				dropped <- err
+			}
+		})
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case err := <-dropped:
+		if !errors.Is(err, api.ErrStreamIdle) {
+			t.Errorf("stream dropped with %v, want %v", err, api.ErrStreamIdle)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("silent stream was not dropped")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscribe did not reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,357 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"time"
+
+	"watcher-client/api"
+)
+
+// demoPage describes a seeded monitor and the successive versions of the
+// text it watches; each consecutive pair becomes one change event.
+type demoPage struct {
+	name     string
+	url      string
+	selector string
+	freq     int
+	active   bool
+	status   string
+	email    string
+	versions []string
+	// statuses optionally overrides the HTTP status per version.
+	statuses []int
+	tags     []string
+}
+
+// demoTags are the tags seeded before the demo pages, which refer to them
+// by name.
+var demoTags = []api.Tag{
+	{Name: "Competitors", Color: "#e5484d"},
+	{Name: "Docs", Color: "#3e63dd"},
+	{Name: "Pricing", Color: "#30a46c"},
+}
+
+var demoPages = []demoPage{
+	{
+		name: "Acme pricing", url: "https://acme.example.com/pricing", selector: "#plans", freq: 3600, active: true, status: "ok", email: "team@example.com", tags: []string{"Competitors", "Pricing"},
+		versions: []string{
+			"Starter $9/month. Team $29/month. Enterprise: contact sales.",
+			"Starter $12/month. Team $29/month. Enterprise: contact sales.",
+			"Starter $12/month. Team $35/month. Enterprise: contact sales.",
+			"Starter $12/month. Team $35/month. Business $79/month. Enterprise: contact sales.",
+		},
+	},
+	{
+		name: "Go release notes", url: "https://go.dev/doc/devel/release", freq: 86400, active: true, status: "ok", tags: []string{"Docs"},
+		versions: []string{
+			"go1.24.3 (released 2025-05-06) includes security fixes.",
+			"go1.24.4 (released 2025-06-05) includes security fixes to crypto/x509 and net/http.",
+			"go1.25.0 (released 2025-08-12) is a major release of Go.",
+		},
+	},
+	{
+		name: "Globex careers", url: "https://globex.example.com/careers", selector: ".openings", freq: 21600, active: true, status: "ok", email: "hiring@example.com", tags: []string{"Competitors"},
+		versions: []string{
+			"Open roles: Backend engineer (Berlin).",
+			"Open roles: Backend engineer (Berlin), Site reliability engineer (remote).",
+			"Open roles: Site reliability engineer (remote).",
+		},
+	},
+	{
+		name: "Initech status", url: "https://status.initech.example.com", freq: 300, active: true, status: "error", tags: []string{"Competitors"},
+		versions: []string{
+			"All systems operational.",
+			"Degraded performance: API latency elevated.",
+			"Service unavailable.",
+		},
+		statuses: []int{200, 200, 503},
+	},
+	{
+		name: "Umbrella API docs", url: "https://docs.umbrella.example.com/api/v2", selector: "main", freq: 43200, active: false, status: "ok", tags: []string{"Docs"},
+		versions: []string{
+			"GET /v2/items returns up to 100 items.",
+			"GET /v2/items returns up to 100 items. Deprecated: use /v3/items.",
+		},
+	},
+}
+
+// SeedDemo registers a demo instance and fills it with monitors, change
+// history, HTML snapshots, text diffs and screenshot diffs. It returns the
+// demo instance credentials.
+func SeedDemo(s *Server) (key, secret string) {
+	key, secret = s.RegisterInstance("demo")
+	now := time.Now().UTC()
+
+	tagIDs := make(map[string]uint64)
+	for _, t := range demoTags {
+		tagIDs[t.Name] = s.AddTag(key, t).ID
+	}
+
+	for pi, page := range demoPages {
+		created := now.Add(-time.Duration(30+pi) * 24 * time.Hour)
+		var selector, email *string
+		if page.selector != "" {
+			selector = &page.selector
+		}
+		if page.email != "" {
+			email = &page.email
+		}
+		var tags []uint64
+		for _, name := range page.tags {
+			tags = append(tags, tagIDs[name])
+		}
+		status := page.status
+		// The backend updates a monitor whenever it records a run.
+		updated := now.Add(-time.Duration(page.freq/2) * time.Second)
+		if page.active {
+			updated = now.Add(-time.Duration(page.freq*(pi+1)/7) * time.Second)
+		}
+		m := s.AddMonitor(key, api.Monitor{
+			Name:             page.name,
+			URL:              page.url,
+			CSSSelector:      selector,
+			FrequencySeconds: page.freq,
+			NotifyEmail:      email != nil,
+			NotifyEmailAddr:  email,
+			Active:           page.active,
+			LastStatus:       &status,
+			TagIDs:           tags,
+			CreatedAt:        created,
+			UpdatedAt:        updated,
+		})
+
+		for vi := 1; vi < len(page.versions); vi++ {
+			at := created.Add(time.Duration(vi) * 6 * 24 * time.Hour).Add(time.Duration(pi*37) * time.Minute)
+			c := demoChange(s, m.ID, pi, vi, page, page.versions[vi-1], page.versions[vi])
+			c.CreatedAt = at
+			if len(page.statuses) == len(page.versions) {
+				prevStatus, currStatus := page.statuses[vi-1], page.statuses[vi]
+				c.HTTPStatusPrev = &prevStatus
+				c.HTTPStatusCurr = &currStatus
+			}
+			s.AddChange(c)
+		}
+	}
+	return key, secret
+}
+
+// demoChange stores the snapshots, diff and screenshots of the vi-th change
+// of a demo page and returns the change event referring to them.
+func demoChange(s *Server, monitorID uint64, pi, vi int, page demoPage, prevText, currText string) api.ChangeEvent {
+	prefix := fmt.Sprintf("m%d-c%d", monitorID, vi)
+	prevHTML := s.PutAsset(prefix+"-prev.html", "text/html; charset=utf-8", []byte(demoHTML(page, prevText)))
+	currHTML := s.PutAsset(prefix+"-curr.html", "text/html; charset=utf-8", []byte(demoHTML(page, currText)))
+	diffJSON, _ := json.Marshal(wordDiff(prevText, currText))
+	diff := s.PutAsset(prefix+"-diff.json", "application/json", diffJSON)
+
+	prevImg, currImg, diffImg := demoScreenshots(pi, min(vi, 8))
+	shotPrev := s.PutAsset(prefix+"-prev.png", "image/png", prevImg)
+	shotCurr := s.PutAsset(prefix+"-curr.png", "image/png", currImg)
+	shotDiff := s.PutAsset(prefix+"-diff.png", "image/png", diffImg)
+
+	return api.ChangeEvent{
+		MonitorID:      monitorID,
+		RunID:          uint64(1000*monitorID) + uint64(vi),
+		HTMLPrev:       &prevHTML,
+		HTMLCurr:       &currHTML,
+		HTMLDiff:       &diff,
+		ScreenshotPrev: &shotPrev,
+		ScreenshotCurr: &shotCurr,
+		ScreenshotDiff: &shotDiff,
+	}
+}
+
+// RunDemoActivity simulates the backend checking the demo monitors of key so
+// the demo shows live updates: every interval one active monitor completes a
+// run, and every third run finds a change. It returns once s is closed.
+func RunDemoActivity(s *Server, key string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	texts := make(map[uint64]string)
+	versions := make(map[uint64]int)
+	for tick := 1; ; tick++ {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		var active []api.Monitor
+		for _, m := range s.Monitors(key) {
+			if m.Active {
+				active = append(active, m)
+			}
+		}
+		if len(active) == 0 {
+			continue
+		}
+		m := active[tick%len(active)]
+		changed := tick%3 == 0
+		status := "ok"
+		if m.LastStatus != nil {
+			status = *m.LastStatus
+		}
+		if changed {
+			// Find the page the monitor was seeded from, if any, to keep the
+			// generated snapshots looking like the rest of its history.
+			pi, page := 0, demoPage{name: m.Name, url: m.URL}
+			for i, p := range demoPages {
+				if p.url == m.URL {
+					pi, page = i, p
+				}
+			}
+			prev, ok := texts[m.ID]
+			if !ok && len(page.versions) > 0 {
+				prev = page.versions[len(page.versions)-1]
+			} else if !ok {
+				prev = "Page content."
+			}
+			if versions[m.ID] == 0 {
+				versions[m.ID] = len(page.versions)
+			}
+			curr := fmt.Sprintf("%s Checked at %s.", strings.SplitN(prev, " Checked at", 2)[0], time.Now().Format("15:04:05"))
+			s.AddChange(demoChange(s, m.ID, pi, versions[m.ID], page, prev, curr))
+			texts[m.ID] = curr
+			versions[m.ID]++
+		}
+		s.CompleteRun(m.ID, status, changed)
+	}
+}
+
+func demoHTML(page demoPage, text string) string {
+	return fmt.Sprintf(`<!doctype html>
+<html><head><title>%s</title></head>
+<body>
+<header><h1>%s</h1></header>
+<main id="plans" class="openings"><p>%s</p></main>
+<footer>Snapshot of %s</footer>
+</body></html>
+`, page.name, page.name, text, page.url)
+}
+
+// wordDiff produces a simple word-level diff in the backend's segment format.
+func wordDiff(prev, curr string) []api.DiffSegment {
+	a, b := splitWords(prev), splitWords(curr)
+
+	// Longest common subsequence over words.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []api.DiffSegment
+	emit := func(text, kind string) {
+		if n := len(out); n > 0 && out[n-1].Kind == kind {
+			out[n-1].Text += text
+			return
+		}
+		out = append(out, api.DiffSegment{Text: text, Kind: kind})
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			emit(a[i], "")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			emit(a[i], api.DiffDeleted)
+			i++
+		default:
+			emit(b[j], api.DiffInserted)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		emit(a[i], api.DiffDeleted)
+	}
+	for ; j < len(b); j++ {
+		emit(b[j], api.DiffInserted)
+	}
+	return out
+}
+
+// splitWords splits s into words that keep their trailing space so the
+// segments concatenate back to the original text.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			words = append(words, s[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// demoScreenshots draws a stylised page before and after a change, plus a
+// diff image with the changed block highlighted.
+func demoScreenshots(page, version int) (prev, curr, diff []byte) {
+	const w, h = 960, 1400
+	accent := []color.RGBA{
+		{0x2b, 0x6c, 0xb0, 0xff},
+		{0x00, 0x7d, 0x9c, 0xff},
+		{0x6b, 0x46, 0xc1, 0xff},
+		{0xc0, 0x56, 0x21, 0xff},
+		{0x2f, 0x85, 0x5a, 0xff},
+	}[page%5]
+
+	render := func(v int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		fill(img, img.Bounds(), color.RGBA{0xfa, 0xfa, 0xfa, 0xff})
+		fill(img, image.Rect(0, 0, w, 90), accent)
+		for row := 0; row < 24; row++ {
+			y := 140 + row*48
+			width := 600 + ((row*97+page*31)%5)*60
+			if row >= 6 && row < 6+v {
+				width = 360 + v*90
+			}
+			fill(img, image.Rect(60, y, 60+width, y+18), color.RGBA{0xc8, 0xc8, 0xc8, 0xff})
+		}
+		fill(img, image.Rect(0, h-70, w, h), color.RGBA{0x33, 0x33, 0x33, 0xff})
+		return img
+	}
+
+	prevImg, currImg := render(version-1), render(version)
+	diffImg := image.NewRGBA(currImg.Bounds())
+	copy(diffImg.Pix, currImg.Pix)
+	changed := image.Rect(40, 140+6*48-10, w-40, 140+(6+version)*48)
+	overlay := image.NewUniform(color.RGBA{0xe5, 0x3e, 0x3e, 0x60})
+	drawOver(diffImg, changed, overlay)
+
+	return encodePNG(prevImg), encodePNG(currImg), encodePNG(diffImg)
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func drawOver(img *image.RGBA, r image.Rectangle, src image.Image) {
+	draw.Draw(img, r, src, image.Point{}, draw.Over)
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
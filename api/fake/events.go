@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"watcher-client/api"
+)
+
+// maxEvents is how many events are kept for clients resuming a stream.
+// Clients that fell further behind are told to resync.
+const maxEvents = 1000
+
+// heartbeatInterval is how often an idle stream sends a keep-alive comment.
+const heartbeatInterval = 15 * time.Second
+
+type event struct {
+	id   uint64
+	key  string
+	typ  string
+	data []byte
+}
+
+// publishLocked records an event for the instance key and wakes the streams
+// waiting for it. s.mu must be held.
+func (s *Server) publishLocked(key, typ string, v any) {
+	data, _ := json.Marshal(v)
+	s.nextEventID++
+	s.events = append(s.events, event{id: s.nextEventID, key: key, typ: typ, data: data})
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	for wake := range s.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// CompleteRun records a finished check of a monitor with the given status
+// and streams a run.completed event for it.
+func (s *Server) CompleteRun(monitorID uint64, status string, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.monitors[monitorID]
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	m.LastStatus = &status
+	// Like the backend, recording the outcome on the monitor updates it.
+	m.UpdatedAt = now
+	s.nextRunID++
+	s.publishLocked(s.owners[monitorID], api.EventRunCompleted, api.RunResult{
+		MonitorID: monitorID,
+		RunID:     s.nextRunID,
+		Status:    status,
+		Changed:   changed,
+		CheckedAt: now,
+	})
+}
+
+// handleEvents streams the events of the instance as Server-Sent Events,
+// starting after Last-Event-ID when the client resumes.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request, key string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "internal", "streaming unsupported", nil)
+		return
+	}
+
+	wake := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.subscribers[wake] = struct{}{}
+	last, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	resync := false
+	if err != nil {
+		// A new subscriber only wants what happens from now on.
+		last = s.nextEventID
+	} else if last > s.nextEventID || (len(s.events) > 0 && s.events[0].id > last+1) {
+		// Unknown or expired ID: the client missed events.
+		last, resync = s.nextEventID, true
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, wake)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 2000\n\n")
+	if resync {
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: {}\n\n", last, api.EventResync)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		s.mu.Lock()
+		var pending []event
+		for _, e := range s.events {
+			if e.id > last && e.key == key {
+				pending = append(pending, e)
+			}
+		}
+		if n := len(s.events); n > 0 {
+			last = max(last, s.events[n-1].id)
+		}
+		s.mu.Unlock()
+
+		for _, e := range pending {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.id, e.typ, e.data)
+		}
+		if len(pending) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-wake:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,558 @@
+// Package fake is an in-memory Watcher backend served over httptest. It
+// implements every endpoint the client uses, verifies request signatures the
+// same way the real backend does, and serves change assets itself, so the
+// client can be exercised in tests and demo mode without a real backend.
+package fake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"watcher-client/api"
+)
+
+type instance struct {
+	name   string
+	secret string
+}
+
+type asset struct {
+	contentType string
+	etag        string
+	data        []byte
+}
+
+// storedResponse is the outcome of a request made under an idempotency key.
+// done is closed once status and body are set; a request with the same key
+// that arrives before then waits for it.
+type storedResponse struct {
+	done   chan struct{}
+	status int
+	body   []byte
+}
+
+// Server is a fake Watcher backend. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	verifier *api.Verifier
+
+	mu            sync.Mutex
+	instances     map[string]*instance
+	monitors      map[uint64]*api.Monitor
+	owners        map[uint64]string
+	changes       map[uint64][]api.ChangeEvent
+	assets        map[string]asset
+	idempotent    map[string]*storedResponse
+	nextMonitorID uint64
+	nextChangeID  uint64
+	nextRequestID uint64
+	nextRunID     uint64
+
+	tags      map[uint64]*api.Tag
+	tagOwners map[uint64]string
+	nextTagID uint64
+
+	events      []event
+	nextEventID uint64
+	subscribers map[chan struct{}]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewServer starts an empty fake backend. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		instances:     make(map[string]*instance),
+		monitors:      make(map[uint64]*api.Monitor),
+		owners:        make(map[uint64]string),
+		changes:       make(map[uint64][]api.ChangeEvent),
+		assets:        make(map[string]asset),
+		idempotent:    make(map[string]*storedResponse),
+		nextMonitorID: 1,
+		nextChangeID:  1,
+		tags:          make(map[uint64]*api.Tag),
+		tagOwners:     make(map[uint64]string),
+		nextTagID:     1,
+		subscribers:   make(map[chan struct{}]struct{}),
+		done:          make(chan struct{}),
+	}
+	s.verifier = api.NewVerifier(s.secretFor)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.HandleFunc("POST /api/instances/register", s.handleRegister)
+	mux.HandleFunc("POST /api/instances/rotate", s.authed(s.handleRotate))
+	mux.HandleFunc("GET /api/monitors", s.authed(s.handleListMonitors))
+	mux.HandleFunc("POST /api/monitors", s.authed(s.handleCreateMonitor))
+	mux.HandleFunc("PATCH /api/monitors/{id}", s.authed(s.handleUpdateMonitor))
+	mux.HandleFunc("PUT /api/monitors/{id}", s.authed(s.handleUpdateMonitor))
+	mux.HandleFunc("DELETE /api/monitors/{id}", s.authed(s.handleDeleteMonitor))
+	mux.HandleFunc("GET /api/monitors/{id}/changes", s.authed(s.handleListChanges))
+	mux.HandleFunc("GET /api/tags", s.authed(s.handleListTags))
+	mux.HandleFunc("POST /api/tags", s.authed(s.handleCreateTag))
+	mux.HandleFunc("PATCH /api/tags/{id}", s.authed(s.handleUpdateTag))
+	mux.HandleFunc("DELETE /api/tags/{id}", s.authed(s.handleDeleteTag))
+	mux.HandleFunc("GET /api/events", s.authed(s.handleEvents))
+	mux.HandleFunc("GET /assets/{name}", s.handleAsset)
+
+	s.Server = httptest.NewServer(s.withRequestID(mux))
+	return s
+}
+
+// Close ends open event streams and shuts the server down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.Server.Close()
+}
+
+func (s *Server) secretFor(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[key]
+	if !ok {
+		return "", false
+	}
+	return inst.secret, true
+}
+
+// RegisterInstance creates instance credentials directly, as if the
+// registration endpoint had been called.
+func (s *Server) RegisterInstance(name string) (key, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registerLocked(name)
+}
+
+func (s *Server) registerLocked(name string) (string, string) {
+	key := "inst_" + randomHex(8)
+	secret := randomHex(32)
+	s.instances[key] = &instance{name: name, secret: secret}
+	return key, secret
+}
+
+// RevokeInstance drops an instance so its requests fail with 401.
+func (s *Server) RevokeInstance(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.instances, key)
+}
+
+// AddMonitor stores m for the instance key, assigning an ID and timestamps
+// when they are zero, and returns the stored copy.
+func (s *Server) AddMonitor(key string, m api.Monitor) api.Monitor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addMonitorLocked(key, m)
+}
+
+func (s *Server) addMonitorLocked(key string, m api.Monitor) api.Monitor {
+	if m.ID == 0 {
+		m.ID = s.nextMonitorID
+	}
+	if m.ID >= s.nextMonitorID {
+		s.nextMonitorID = m.ID + 1
+	}
+	now := time.Now().UTC()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	if m.UpdatedAt.IsZero() {
+		m.UpdatedAt = m.CreatedAt
+	}
+	stored := m
+	s.monitors[m.ID] = &stored
+	s.owners[m.ID] = key
+	s.publishLocked(key, api.EventMonitorCreated, stored)
+	return stored
+}
+
+// AddChange records a change event for a monitor and returns it with its
+// ID filled in. Changes are listed newest first by CreatedAt.
+func (s *Server) AddChange(c api.ChangeEvent) api.ChangeEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.ID == 0 {
+		c.ID = s.nextChangeID
+	}
+	if c.ID >= s.nextChangeID {
+		s.nextChangeID = c.ID + 1
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	list := append(s.changes[c.MonitorID], c)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	s.changes[c.MonitorID] = list
+	if key, ok := s.owners[c.MonitorID]; ok {
+		s.publishLocked(key, api.EventChangeDetected, c)
+	}
+	return c
+}
+
+// PutAsset serves data under /assets/name and returns its absolute URL.
+func (s *Server) PutAsset(name, contentType string, data []byte) string {
+	s.mu.Lock()
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	s.assets[name] = asset{contentType: contentType, etag: etag, data: data}
+	s.mu.Unlock()
+	return s.URL + "/assets/" + url.PathEscape(name)
+}
+
+// Monitors returns a snapshot of the monitors owned by key.
+func (s *Server) Monitors(key string) []api.Monitor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.monitorsLocked(key)
+}
+
+func (s *Server) monitorsLocked(key string) []api.Monitor {
+	out := []api.Monitor{}
+	for id, m := range s.monitors {
+		if s.owners[id] == key {
+			out = append(out, *m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// --- middleware ---
+
+func (s *Server) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.nextRequestID++
+		id := fmt.Sprintf("fake-%06d", s.nextRequestID)
+		s.mu.Unlock()
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, key string)
+
+// authed verifies the request signature and replays stored responses for
+// repeated idempotency keys. The first request with a key claims it before
+// running, so concurrent requests with the same key run the handler once.
+func (s *Server) authed(h authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := s.verifier.Verify(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+			return
+		}
+
+		idemKey := r.Header.Get("Idempotency-Key")
+		if idemKey == "" {
+			h(w, r, key)
+			return
+		}
+		scoped := key + ":" + r.Method + ":" + r.URL.Path + ":" + idemKey
+		s.mu.Lock()
+		stored, seen := s.idempotent[scoped]
+		if !seen {
+			stored = &storedResponse{done: make(chan struct{})}
+			s.idempotent[scoped] = stored
+		}
+		s.mu.Unlock()
+		if seen {
+			select {
+			case <-stored.done:
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.status)
+			w.Write(stored.body)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		h(rec, r, key)
+		stored.status, stored.body = rec.Code, rec.Body.Bytes()
+		if rec.Code >= 500 {
+			// Failures are not replayed; the next attempt runs again.
+			s.mu.Lock()
+			delete(s.idempotent, scoped)
+			s.mu.Unlock()
+		}
+		close(stored.done)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}
+}
+
+// --- handlers ---
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+type credentialsResp struct {
+	InstanceKey    string `json:"instance_key"`
+	InstanceSecret string `json:"instance_secret"`
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body", nil)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", map[string]string{"name": "is required"})
+		return
+	}
+	key, secret := s.RegisterInstance(req.Name)
+	writeJSON(w, http.StatusCreated, credentialsResp{InstanceKey: key, InstanceSecret: secret})
+}
+
+func (s *Server) handleRotate(w http.ResponseWriter, _ *http.Request, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[key]
+	if !ok {
+		// Revoked while the request was being verified.
+		writeError(w, http.StatusUnauthorized, "unauthorized", "unknown instance key", nil)
+		return
+	}
+	inst.secret = randomHex(32)
+	writeJSON(w, http.StatusOK, credentialsResp{InstanceKey: key, InstanceSecret: inst.secret})
+}
+
+func (s *Server) handleListMonitors(w http.ResponseWriter, _ *http.Request, key string) {
+	writeJSON(w, http.StatusOK, s.Monitors(key))
+}
+
+func (s *Server) handleCreateMonitor(w http.ResponseWriter, r *http.Request, key string) {
+	var req api.CreateMonitorReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body", nil)
+		return
+	}
+	fields := validateMonitor(&req.URL, &req.FrequencySeconds, req.NotifyEmail, &req.NotifyEmailAddr)
+	s.mu.Lock()
+	tagIDs := s.checkTagsLocked(key, req.TagIDs, fields)
+	s.mu.Unlock()
+	if len(fields) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", fields)
+		return
+	}
+
+	m := api.Monitor{
+		Name:             req.Name,
+		URL:              req.URL,
+		CSSSelector:      nonEmpty(req.CSSSelector),
+		FrequencySeconds: req.FrequencySeconds,
+		NotifyEmail:      req.NotifyEmail,
+		NotifyEmailAddr:  nonEmpty(&req.NotifyEmailAddr),
+		Active:           true,
+		TagIDs:           tagIDs,
+	}
+	if m.Name == "" {
+		m.Name = m.URL
+	}
+	writeJSON(w, http.StatusCreated, s.AddMonitor(key, m))
+}
+
+func (s *Server) handleUpdateMonitor(w http.ResponseWriter, r *http.Request, key string) {
+	id, ok := s.ownedMonitorID(w, r, key)
+	if !ok {
+		return
+	}
+	var req api.UpdateMonitorReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body", nil)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.monitors[id].Apply(req)
+	notifyAddr := ""
+	if m.NotifyEmailAddr != nil {
+		notifyAddr = *m.NotifyEmailAddr
+	}
+	fields := validateMonitor(&m.URL, &m.FrequencySeconds, m.NotifyEmail, &notifyAddr)
+	m.TagIDs = s.checkTagsLocked(key, m.TagIDs, fields)
+	if len(fields) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", fields)
+		return
+	}
+	m.UpdatedAt = time.Now().UTC()
+	s.monitors[id] = &m
+	s.publishLocked(key, api.EventMonitorUpdated, m)
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) handleDeleteMonitor(w http.ResponseWriter, r *http.Request, key string) {
+	id, ok := s.ownedMonitorID(w, r, key)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	delete(s.monitors, id)
+	delete(s.owners, id)
+	delete(s.changes, id)
+	s.publishLocked(key, api.EventMonitorDeleted, map[string]uint64{"id": id})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListChanges(w http.ResponseWriter, r *http.Request, key string) {
+	id, ok := s.ownedMonitorID(w, r, key)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	limit := api.DefaultChangesPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid limit", map[string]string{"limit": "must be between 1 and 500"})
+			return
+		}
+		limit = n
+	}
+	offset := 0
+	if v := q.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid cursor", nil)
+			return
+		}
+		offset = n
+	}
+	before, errB := parseOptionalTime(q.Get("before"))
+	after, errA := parseOptionalTime(q.Get("after"))
+	if errB != nil || errA != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "before/after must be RFC 3339 times", nil)
+		return
+	}
+
+	s.mu.Lock()
+	var matching []api.ChangeEvent
+	for _, c := range s.changes[id] {
+		if !before.IsZero() && !c.CreatedAt.Before(before) {
+			continue
+		}
+		if !after.IsZero() && !c.CreatedAt.After(after) {
+			continue
+		}
+		matching = append(matching, c)
+	}
+	s.mu.Unlock()
+
+	page := api.ChangesPage{Items: []api.ChangeEvent{}, Total: len(matching)}
+	if offset < len(matching) {
+		end := min(offset+limit, len(matching))
+		page.Items = matching[offset:end]
+		if end < len(matching) {
+			page.NextCursor = strconv.Itoa(end)
+		}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	a, ok := s.assets[r.PathValue("name")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "asset not found", nil)
+		return
+	}
+	w.Header().Set("Content-Type", a.contentType)
+	w.Header().Set("ETag", a.etag)
+	http.ServeContent(w, r, r.PathValue("name"), time.Time{}, bytes.NewReader(a.data))
+}
+
+// ownedMonitorID resolves the {id} path value to a monitor owned by key,
+// writing a 404 otherwise so instances cannot see each other's monitors.
+func (s *Server) ownedMonitorID(w http.ResponseWriter, r *http.Request, key string) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err == nil {
+		s.mu.Lock()
+		owner, exists := s.owners[id]
+		s.mu.Unlock()
+		if exists && owner == key {
+			return id, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "monitor not found", nil)
+	return 0, false
+}
+
+// --- helpers ---
+
+func validateMonitor(rawURL *string, freq *int, notify bool, notifyAddr *string) map[string]string {
+	fields := map[string]string{}
+	*rawURL = strings.TrimSpace(*rawURL)
+	if u, err := url.Parse(*rawURL); *rawURL == "" || err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		fields["url"] = "must be an absolute http(s) URL"
+	}
+	if *freq < 30 {
+		fields["frequency_seconds"] = "must be at least 30 seconds"
+	}
+	if notify && !strings.Contains(*notifyAddr, "@") {
+		fields["notify_email_address"] = "must be a valid email address"
+	}
+	return fields
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string, fields map[string]string) {
+	body := map[string]any{"code": code, "message": msg}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	writeJSON(w, status, map[string]any{"error": body})
+}
+
+func parseOptionalTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+func nonEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
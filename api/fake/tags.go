@@ -0,0 +1,169 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"watcher-client/api"
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// AddTag stores t for the instance key, assigning an ID when it is zero, and
+// returns the stored copy.
+func (s *Server) AddTag(key string, t api.Tag) api.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addTagLocked(key, t)
+}
+
+func (s *Server) addTagLocked(key string, t api.Tag) api.Tag {
+	if t.ID == 0 {
+		t.ID = s.nextTagID
+	}
+	if t.ID >= s.nextTagID {
+		s.nextTagID = t.ID + 1
+	}
+	stored := t
+	s.tags[t.ID] = &stored
+	s.tagOwners[t.ID] = key
+	s.publishLocked(key, api.EventTagCreated, t)
+	return t
+}
+
+// Tags returns a snapshot of the tags owned by key, sorted by name.
+func (s *Server) Tags(key string) []api.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []api.Tag{}
+	for id, t := range s.tags {
+		if s.tagOwners[id] == key {
+			out = append(out, *t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+	return out
+}
+
+func (s *Server) handleListTags(w http.ResponseWriter, _ *http.Request, key string) {
+	writeJSON(w, http.StatusOK, s.Tags(key))
+}
+
+func (s *Server) handleCreateTag(w http.ResponseWriter, r *http.Request, key string) {
+	var req api.CreateTagReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body", nil)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := api.Tag{Name: strings.TrimSpace(req.Name), Color: req.Color}
+	if fields := s.validateTagLocked(key, t); len(fields) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", fields)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.addTagLocked(key, t))
+}
+
+func (s *Server) handleUpdateTag(w http.ResponseWriter, r *http.Request, key string) {
+	id, ok := s.ownedTagID(w, r, key)
+	if !ok {
+		return
+	}
+	var req api.UpdateTagReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body", nil)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *s.tags[id]
+	if req.Name != nil {
+		t.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Color != nil {
+		t.Color = *req.Color
+	}
+	if fields := s.validateTagLocked(key, t); len(fields) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", fields)
+		return
+	}
+	s.tags[id] = &t
+	s.publishLocked(key, api.EventTagUpdated, t)
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleDeleteTag(w http.ResponseWriter, r *http.Request, key string) {
+	id, ok := s.ownedTagID(w, r, key)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	delete(s.tags, id)
+	delete(s.tagOwners, id)
+	for mid, m := range s.monitors {
+		if s.owners[mid] != key || !m.HasTag(id) {
+			continue
+		}
+		m.TagIDs = slices.DeleteFunc(slices.Clone(m.TagIDs), func(t uint64) bool { return t == id })
+		s.publishLocked(key, api.EventMonitorUpdated, *m)
+	}
+	s.publishLocked(key, api.EventTagDeleted, map[string]uint64{"id": id})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateTagLocked checks t's name and color; names are unique per
+// instance, ignoring case. s.mu must be held.
+func (s *Server) validateTagLocked(key string, t api.Tag) map[string]string {
+	fields := map[string]string{}
+	if t.Name == "" {
+		fields["name"] = "is required"
+	}
+	for id, other := range s.tags {
+		if id != t.ID && s.tagOwners[id] == key && strings.EqualFold(other.Name, t.Name) {
+			fields["name"] = "is already in use"
+		}
+	}
+	if t.Color != "" && !tagColorPattern.MatchString(t.Color) {
+		fields["color"] = "must be a #rrggbb color"
+	}
+	return fields
+}
+
+// checkTagsLocked drops duplicate tag IDs and reports IDs that are not tags
+// of the instance key in fields. s.mu must be held.
+func (s *Server) checkTagsLocked(key string, ids []uint64, fields map[string]string) []uint64 {
+	var out []uint64
+	for _, id := range ids {
+		if s.tagOwners[id] != key {
+			fields["tag_ids"] = "unknown tag " + strconv.FormatUint(id, 10)
+			continue
+		}
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// ownedTagID resolves the {id} path value to a tag owned by key, writing a
+// 404 otherwise.
+func (s *Server) ownedTagID(w http.ResponseWriter, r *http.Request, key string) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err == nil {
+		s.mu.Lock()
+		owner, exists := s.tagOwners[id]
+		s.mu.Unlock()
+		if exists && owner == key {
+			return id, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "tag not found", nil)
+	return 0, false
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	mrand "math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how Client retries transient failures such as
+// 502/503/504 responses and dropped connections.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction (0..1) of each delay that is randomised.
+	Jitter float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   300 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.5,
+}
+
+// NoRetry disables retries entirely.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay before retry number n (1-based).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(n-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d = d*(1-j) + d*j*mrand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// delay picks the wait before the next attempt, preferring the server's
+// Retry-After header when it is present and not longer than MaxDelay allows.
+func (p RetryPolicy) delay(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 && d > p.MaxDelay {
+				d = p.MaxDelay
+			}
+			return d
+		}
+	}
+	return p.backoff(n)
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// canRetry reports whether a request may be sent again without side effects:
+// safe and idempotent methods always, anything else only with an idempotency key.
+// PATCH counts as idempotent because the backend's patches only set fields.
+func canRetry(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transientNetError reports whether err is a network failure that may clear
+// up by itself: a timeout, a refused, reset or unreachable connection, one
+// cut off mid-response, or a temporary DNS failure. TLS and certificate
+// errors, unknown hosts and malformed URLs are not; retrying them would only
+// delay the real error.
+func transientNetError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	for _, target := range []error{
+		syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.EPIPE,
+		syscall.ENETUNREACH, syscall.EHOSTUNREACH, io.ErrUnexpectedEOF,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// NewIdempotencyKey returns a random Idempotency-Key value.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,266 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+// fastRetry keeps the retry tests quick.
+var fastRetry = api.RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// flakyProxy sits in front of a fake backend. For the first failures
+// requests of each method it answers 503; with lose set it lets the backend
+// handle the request first, as if the response was lost on the way back.
+type flakyProxy struct {
+	*httptest.Server
+	failures int
+	lose     bool
+
+	mu       sync.Mutex
+	attempts map[string]int
+	keys     map[string][]string
+}
+
+func newFlakyProxy(t *testing.T, backend *fake.Server, failures int, lose bool) *flakyProxy {
+	p := &flakyProxy{failures: failures, lose: lose, attempts: make(map[string]int), keys: make(map[string][]string)}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.attempts[r.Method]++
+		p.keys[r.Method] = append(p.keys[r.Method], r.Header.Get("Idempotency-Key"))
+		fail := p.attempts[r.Method] <= p.failures
+		p.mu.Unlock()
+		if !fail {
+			backend.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		if p.lose {
+			backend.Config.Handler.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *flakyProxy) count(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts[method]
+}
+
+func TestGetIsRetried(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	proxy := newFlakyProxy(t, srv, 2, false)
+
+	c := api.NewClient(proxy.URL, key, secret)
+	c.Retry = fastRetry
+	if _, err := c.ListMonitorsContext(context.Background()); err != nil {
+		t.Fatalf("ListMonitors: %v", err)
+	}
+	if n := proxy.count("GET"); n != 3 {
+		t.Errorf("GET sent %d times, want 3", n)
+	}
+}
+
+func TestRetriesGiveUpAfterMaxAttempts(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	proxy := newFlakyProxy(t, srv, 100, false)
+
+	c := api.NewClient(proxy.URL, key, secret)
+	c.Retry = fastRetry
+	_, err := c.ListMonitorsContext(context.Background())
+	if !api.IsUnreachable(err) {
+		t.Fatalf("ListMonitors error = %v, want an unreachable error", err)
+	}
+	if n := proxy.count("GET"); n != fastRetry.MaxAttempts {
+		t.Errorf("GET sent %d times, want %d", n, fastRetry.MaxAttempts)
+	}
+}
+
+func TestCreateWithLostResponseIsAppliedOnce(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	proxy := newFlakyProxy(t, srv, 2, true)
+
+	c := api.NewClient(proxy.URL, key, secret)
+	c.Retry = fastRetry
+	m, err := c.CreateMonitorContext(context.Background(), api.CreateMonitorReq{
+		Name: "Example", URL: "https://example.com", FrequencySeconds: 60,
+	})
+	if err != nil {
+		t.Fatalf("CreateMonitor: %v", err)
+	}
+	if n := proxy.count("POST"); n != 3 {
+		t.Errorf("POST sent %d times, want 3", n)
+	}
+	proxy.mu.Lock()
+	keys := proxy.keys["POST"]
+	proxy.mu.Unlock()
+	for _, k := range keys {
+		if k == "" || k != keys[0] {
+			t.Fatalf("idempotency keys = %q, want the same non-empty key on every attempt", keys)
+		}
+	}
+	ms := srv.Monitors(key)
+	if len(ms) != 1 || ms[0].ID != m.ID {
+		t.Fatalf("backend has %d monitors (%+v), want only the created one", len(ms), ms)
+	}
+}
+
+func TestCreateOnceReplaysUnderTheSameKey(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+
+	c := api.NewClient(srv.URL, key, secret)
+	req := api.CreateMonitorReq{Name: "Example", URL: "https://example.com", FrequencySeconds: 60}
+	idem := api.NewIdempotencyKey()
+	first, err := c.CreateMonitorOnce(context.Background(), idem, req)
+	if err != nil {
+		t.Fatalf("first create: %v", err)
+	}
+	again, err := c.CreateMonitorOnce(context.Background(), idem, req)
+	if err != nil {
+		t.Fatalf("second create: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("second create returned monitor %d, want %d", again.ID, first.ID)
+	}
+	if n := len(srv.Monitors(key)); n != 1 {
+		t.Errorf("backend has %d monitors, want 1", n)
+	}
+}
+
+func TestPatchIsRetried(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	proxy := newFlakyProxy(t, srv, 1, false)
+
+	c := api.NewClient(proxy.URL, key, secret)
+	c.Retry = fastRetry
+	active := false
+	got, err := c.UpdateMonitorContext(context.Background(), m.ID, api.UpdateMonitorReq{Active: &active})
+	if err != nil {
+		t.Fatalf("UpdateMonitor: %v", err)
+	}
+	if got.Active {
+		t.Error("monitor still active after update")
+	}
+	if n := proxy.count("PATCH"); n != 2 {
+		t.Errorf("PATCH sent %d times, want 2", n)
+	}
+}
+
+func TestRotateIsNotRetried(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	proxy := newFlakyProxy(t, srv, 1, true)
+
+	c := api.NewClient(proxy.URL, key, secret)
+	c.Retry = fastRetry
+	if _, _, err := c.RotateSecret(context.Background()); !api.IsUnreachable(err) {
+		t.Fatalf("RotateSecret error = %v, want an unreachable error", err)
+	}
+	if n := proxy.count("POST"); n != 1 {
+		t.Errorf("rotate sent %d times, want 1", n)
+	}
+}
+
+func TestRotateLeavesCredentialsToTheCaller(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+
+	c := api.NewClient(srv.URL, key, secret)
+	newKey, newSecret, err := c.RotateSecret(context.Background())
+	if err != nil {
+		t.Fatalf("RotateSecret: %v", err)
+	}
+	if k, s := c.Credentials(); k != key || s != secret {
+		t.Fatal("RotateSecret switched the client's credentials itself")
+	}
+
+	// The old secret is dead as soon as the backend answered.
+	_, err = c.ListMonitorsContext(context.Background())
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("request with the old secret: error = %v, want 401", err)
+	}
+	c.SetCredentials(newKey, newSecret)
+	if _, err := c.ListMonitorsContext(context.Background()); err != nil {
+		t.Fatalf("request with the new secret: %v", err)
+	}
+}
+
+func TestRetryWaitStopsOnCancel(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	c := api.NewClient(down.URL, "inst_a", "secret")
+	c.Retry = api.RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.ListMonitorsContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ListMonitors error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("ListMonitors took %v after its context expired", d)
+	}
+}
+
+func TestTLSErrorIsNotRetried(t *testing.T) {
+	var mu sync.Mutex
+	var conns int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// The client does not trust the test server's certificate.
+	c := api.NewClient(srv.URL, "inst_a", "secret")
+	c.Retry = fastRetry
+	_, err := c.ListMonitorsContext(context.Background())
+	if err == nil {
+		t.Fatal("ListMonitors succeeded against an untrusted certificate")
+	}
+	if api.IsUnreachable(err) {
+		t.Errorf("certificate error %v counted as unreachable", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 {
+		t.Errorf("connected %d times, want 1", conns)
+	}
+}
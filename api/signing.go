@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Requests are authenticated with an HMAC-SHA256 signature over the method,
+// path, timestamp, nonce and body hash, keyed by the instance secret. The
+// secret itself is only ever transmitted once, in the registration response.
+const (
+	HeaderInstanceKey = "X-Instance-Key"
+	HeaderTimestamp   = "X-Timestamp"
+	HeaderNonce       = "X-Nonce"
+	HeaderContentHash = "X-Content-SHA256"
+	HeaderSignature   = "X-Signature"
+)
+
+// DefaultMaxClockSkew is how far a request timestamp may drift from the
+// verifier's clock before the request is rejected.
+const DefaultMaxClockSkew = 5 * time.Minute
+
+var (
+	ErrSignatureMissing = errors.New("signature headers missing")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrSignatureExpired = errors.New("signature timestamp outside allowed window")
+	ErrSignatureReplay  = errors.New("signature nonce already used")
+	ErrUnknownInstance  = errors.New("unknown instance key")
+)
+
+// SignRequest adds the authentication headers to req. body must be the exact
+// bytes that will be sent (nil for no body).
+func SignRequest(req *http.Request, key, secret string, body []byte, now time.Time) {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+
+	ts := strconv.FormatInt(now.Unix(), 10)
+	bodyHash := sha256.Sum256(body)
+	hashHex := hex.EncodeToString(bodyHash[:])
+	nonceHex := hex.EncodeToString(nonce)
+
+	req.Header.Set(HeaderInstanceKey, key)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderNonce, nonceHex)
+	req.Header.Set(HeaderContentHash, hashHex)
+	req.Header.Set(HeaderSignature, signature(secret, req.Method, req.URL.RequestURI(), ts, nonceHex, hashHex))
+}
+
+func signature(secret, method, requestURI, ts, nonce, bodyHash string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{method, requestURI, ts, nonce, bodyHash}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier checks signed requests on the server side. It is used by the
+// fake backend and is safe for concurrent use.
+type Verifier struct {
+	// Secret looks up the secret for an instance key.
+	Secret  func(key string) (string, bool)
+	MaxSkew time.Duration
+	Now     func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewVerifier(secret func(key string) (string, bool)) *Verifier {
+	return &Verifier{
+		Secret:  secret,
+		MaxSkew: DefaultMaxClockSkew,
+		Now:     time.Now,
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+// Verify authenticates r and returns its instance key. The request body is
+// read and replaced so handlers can still decode it.
+func (v *Verifier) Verify(r *http.Request) (string, error) {
+	key := r.Header.Get(HeaderInstanceKey)
+	ts := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	hashHex := r.Header.Get(HeaderContentHash)
+	sig := r.Header.Get(HeaderSignature)
+	if key == "" || ts == "" || nonce == "" || hashHex == "" || sig == "" {
+		return "", ErrSignatureMissing
+	}
+
+	secret, ok := v.Secret(key)
+	if !ok {
+		return "", ErrUnknownInstance
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", ErrSignatureInvalid
+	}
+	now := v.Now()
+	sent := time.Unix(unix, 0)
+	if d := now.Sub(sent); d > v.MaxSkew || d < -v.MaxSkew {
+		return "", ErrSignatureExpired
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	sum := sha256.Sum256(body)
+	if !hmac.Equal([]byte(hex.EncodeToString(sum[:])), []byte(hashHex)) {
+		return "", ErrSignatureInvalid
+	}
+
+	want := signature(secret, r.Method, r.URL.RequestURI(), ts, nonce, hashHex)
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return "", ErrSignatureInvalid
+	}
+
+	if !v.rememberNonce(key+":"+nonce, sent, now) {
+		return "", ErrSignatureReplay
+	}
+	return key, nil
+}
+
+// rememberNonce records a nonce and reports whether it was unseen. Nonces
+// older than the skew window are dropped since their timestamps would fail anyway.
+func (v *Verifier) rememberNonce(nonce string, sent, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.nonces == nil {
+		v.nonces = make(map[string]time.Time)
+	}
+	for n, t := range v.nonces {
+		if now.Sub(t) > v.MaxSkew {
+			delete(v.nonces, n)
+		}
+	}
+	if _, seen := v.nonces[nonce]; seen {
+		return false
+	}
+	v.nonces[nonce] = sent
+	return true
+}
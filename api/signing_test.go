@@ -0,0 +1,207 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+func TestSignedRequestsAreAccepted(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+
+	ms, err := api.NewClient(srv.URL, key, secret).ListMonitorsContext(context.Background())
+	if err != nil {
+		t.Fatalf("ListMonitors: %v", err)
+	}
+	if len(ms) != 1 || ms[0].Name != "Example" {
+		t.Fatalf("ListMonitors = %+v, want the one monitor", ms)
+	}
+}
+
+func TestWrongSecretIsRejected(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, _ := srv.RegisterInstance("test")
+
+	c := api.NewClient(srv.URL, key, "not the secret")
+	var unauthorized int
+	c.OnUnauthorized = func(*api.APIError) { unauthorized++ }
+	_, err := c.ListMonitorsContext(context.Background())
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("ListMonitors error = %v, want 401", err)
+	}
+	if unauthorized != 1 {
+		t.Errorf("OnUnauthorized called %d times, want 1", unauthorized)
+	}
+}
+
+func TestReplayedRequestIsRejected(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+
+	req, err := http.NewRequest("GET", srv.URL+"/api/monitors", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.SignRequest(req, key, secret, nil, time.Now())
+	send := func() int {
+		resp, err := http.DefaultClient.Do(req.Clone(context.Background()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := send(); code != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", code)
+	}
+	if code := send(); code != http.StatusUnauthorized {
+		t.Fatalf("replayed request: status %d, want 401", code)
+	}
+}
+
+func TestRevokedInstanceIsRejected(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	srv.RevokeInstance(key)
+
+	_, err := api.NewClient(srv.URL, key, secret).ListMonitorsContext(context.Background())
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("ListMonitors error = %v, want 401", err)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	secrets := map[string]string{"inst_a": "secret-a"}
+	body := []byte(`{"name":"x"}`)
+
+	tests := []struct {
+		name   string
+		sign   func(r *http.Request)
+		modify func(r *http.Request)
+		want   error
+	}{
+		{
+			name: "valid",
+			sign: func(r *http.Request) { api.SignRequest(r, "inst_a", "secret-a", body, now) },
+		},
+		{
+			name: "unsigned",
+			sign: func(*http.Request) {},
+			want: api.ErrSignatureMissing,
+		},
+		{
+			name: "unknown key",
+			sign: func(r *http.Request) { api.SignRequest(r, "inst_b", "secret-a", body, now) },
+			want: api.ErrUnknownInstance,
+		},
+		{
+			name: "wrong secret",
+			sign: func(r *http.Request) { api.SignRequest(r, "inst_a", "secret-b", body, now) },
+			want: api.ErrSignatureInvalid,
+		},
+		{
+			name: "tampered body",
+			sign: func(r *http.Request) { api.SignRequest(r, "inst_a", "secret-a", body, now) },
+			modify: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"name":"y"}`))
+			},
+			want: api.ErrSignatureInvalid,
+		},
+		{
+			name: "tampered path",
+			sign: func(r *http.Request) { api.SignRequest(r, "inst_a", "secret-a", body, now) },
+			modify: func(r *http.Request) {
+				r.URL.Path = "/api/monitors/2"
+				r.RequestURI = r.URL.RequestURI()
+			},
+			want: api.ErrSignatureInvalid,
+		},
+		{
+			name: "too old",
+			sign: func(r *http.Request) {
+				api.SignRequest(r, "inst_a", "secret-a", body, now.Add(-api.DefaultMaxClockSkew-time.Minute))
+			},
+			want: api.ErrSignatureExpired,
+		},
+		{
+			name: "from the future",
+			sign: func(r *http.Request) {
+				api.SignRequest(r, "inst_a", "secret-a", body, now.Add(api.DefaultMaxClockSkew+time.Minute))
+			},
+			want: api.ErrSignatureExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := api.NewVerifier(func(key string) (string, bool) {
+				s, ok := secrets[key]
+				return s, ok
+			})
+			v.Now = func() time.Time { return now }
+			r := httptest.NewRequest("PATCH", "/api/monitors/1", bytes.NewReader(body))
+			tt.sign(r)
+			if tt.modify != nil {
+				tt.modify(r)
+			}
+			key, err := v.Verify(r)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.want)
+			}
+			if err == nil && key != "inst_a" {
+				t.Errorf("Verify key = %q, want inst_a", key)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsReusedNonce(t *testing.T) {
+	v := api.NewVerifier(func(string) (string, bool) { return "secret", true })
+	r := httptest.NewRequest("GET", "/api/monitors", nil)
+	api.SignRequest(r, "inst_a", "secret", nil, time.Now())
+
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+	if _, err := v.Verify(r); !errors.Is(err, api.ErrSignatureReplay) {
+		t.Fatalf("second Verify error = %v, want %v", err, api.ErrSignatureReplay)
+	}
+
+	// The same request signed again carries a fresh nonce.
+	api.SignRequest(r, "inst_a", "secret", nil, time.Now())
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Verify of re-signed request: %v", err)
+	}
+}
+
+func TestVerifierKeepsBodyReadable(t *testing.T) {
+	body := []byte(`{"name":"x"}`)
+	v := api.NewVerifier(func(string) (string, bool) { return "secret", true })
+	r := httptest.NewRequest("POST", "/api/monitors", bytes.NewReader(body))
+	api.SignRequest(r, "inst_a", "secret", body, time.Now())
+
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	got, err := io.ReadAll(r.Body)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("body after Verify = %q, %v; want %q", got, err, body)
+	}
+}
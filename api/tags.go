@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tag groups monitors. Monitors refer to tags by ID in Monitor.TagIDs.
+type Tag struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+	// Color is a "#rrggbb" hex color, or empty for the default.
+	Color string `json:"color,omitempty"`
+}
+
+type CreateTagReq struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// UpdateTagReq renames or recolors a tag; nil fields are left unchanged.
+type UpdateTagReq struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// ListTags returns the tags of the instance. Backends without tag support
+// answer with ErrNotFound.
+func (c *Client) ListTags(ctx context.Context) ([]Tag, error) {
+	var tags []Tag
+	err := c.do(ctx, "GET", "/api/tags", nil, &tags)
+	return tags, err
+}
+
+func (c *Client) CreateTag(ctx context.Context, req CreateTagReq) (*Tag, error) {
+	var t Tag
+	err := c.do(ctx, "POST", "/api/tags", req, &t, withIdempotencyKey(NewIdempotencyKey()))
+	return &t, err
+}
+
+func (c *Client) UpdateTag(ctx context.Context, id uint64, req UpdateTagReq) (*Tag, error) {
+	var t Tag
+	err := c.do(ctx, "PATCH", fmt.Sprintf("/api/tags/%d", id), req, &t)
+	return &t, err
+}
+
+// DeleteTag deletes a tag and removes it from all monitors.
+func (c *Client) DeleteTag(ctx context.Context, id uint64) error {
+	return c.do(ctx, "DELETE", fmt.Sprintf("/api/tags/%d", id), nil, nil)
+}
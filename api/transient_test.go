@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTransientNetError(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://backend.example.com/api/monitors", Err: err}
+	}
+	dial := func(err error) error {
+		return urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", err)})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"refused", dial(syscall.ECONNREFUSED), true},
+		{"reset", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"broken pipe", urlErr(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}), true},
+		{"network unreachable", dial(syscall.ENETUNREACH), true},
+		{"unexpected EOF", urlErr(io.ErrUnexpectedEOF), true},
+		{"dial timeout", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}), true},
+		{"temporary DNS failure", urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "server misbehaving", Name: "backend.example.com", IsTemporary: true}}), true},
+		{"unknown host", urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "backend.example.com", IsNotFound: true}}), false},
+		{"untrusted certificate", urlErr(&tlsCertError{x509.UnknownAuthorityError{}}), false},
+		{"bad scheme", urlErr(errors.New("unsupported protocol scheme \"htp\"")), false},
+		{"cancelled", urlErr(context.Canceled), false},
+		{"deadline", urlErr(context.DeadlineExceeded), false},
+		{"clean EOF", urlErr(io.EOF), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientNetError(tt.err); got != tt.want {
+				t.Errorf("transientNetError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"refused", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"deadline", &url.Error{Op: "Get", Err: context.DeadlineExceeded}, true},
+		{"bad gateway", &APIError{StatusCode: http.StatusBadGateway}, true},
+		{"server error", &APIError{StatusCode: http.StatusInternalServerError}, false},
+		{"cancelled", &url.Error{Op: "Get", Err: context.Canceled}, false},
+		{"untrusted certificate", &url.Error{Op: "Get", Err: &tlsCertError{x509.UnknownAuthorityError{}}}, false},
+		{"unknown host", &url.Error{Op: "Get", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"bad URL", &url.Error{Op: "parse", Err: errors.New("invalid port")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnreachable(tt.err); got != tt.want {
+				t.Errorf("IsUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// tlsCertError wraps a certificate error the way crypto/tls reports it.
+type tlsCertError struct{ err error }
+
+func (e *tlsCertError) Error() string { return "tls: failed to verify certificate: " + e.err.Error() }
+func (e *tlsCertError) Unwrap() error { return e.err }
+
+// failingTransport fails every request with err and counts them.
+type failingTransport struct {
+	err   error
+	calls int
+}
+
+func (f *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestUnknownHostIsNotRetried(t *testing.T) {
+	tr := &failingTransport{err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "backend.invalid", IsNotFound: true}}}
+	c := NewClient("http://backend.invalid", "inst_a", "secret")
+	c.httpClient = &http.Client{Transport: tr}
+	c.Retry = RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}
+
+	if _, err := c.ListMonitorsContext(context.Background()); err == nil {
+		t.Fatal("ListMonitors succeeded")
+	}
+	if tr.calls != 1 {
+		t.Errorf("sent %d times, want 1", tr.calls)
+	}
+
+	tr.err, tr.calls = &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 0
+	c.ListMonitorsContext(context.Background())
+	if tr.calls != 4 {
+		t.Errorf("refused connection sent %d times, want 4", tr.calls)
+	}
+}
@@ -1,6 +1,11 @@
 package api
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"time"
+)
 
 type Monitor struct {
 	ID               uint64    `json:"id"`
@@ -12,10 +17,54 @@ type Monitor struct {
 	NotifyEmailAddr  *string   `json:"notify_email_address,omitempty"`
 	Active           bool      `json:"active"`
 	LastStatus       *string   `json:"last_status,omitempty"`
+	TagIDs           []uint64  `json:"tag_ids,omitempty"`
 	UpdatedAt        time.Time `json:"updated_at"`
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// Apply returns a copy of m with the fields set in req changed, as the
+// backend would after a successful UpdateMonitor.
+func (m Monitor) Apply(req UpdateMonitorReq) Monitor {
+	if req.Name != nil {
+		m.Name = *req.Name
+	}
+	if req.URL != nil {
+		m.URL = *req.URL
+	}
+	if req.CSSSelector != nil {
+		m.CSSSelector = nil
+		if *req.CSSSelector != "" {
+			css := *req.CSSSelector
+			m.CSSSelector = &css
+		}
+	}
+	if req.FrequencySeconds != nil {
+		m.FrequencySeconds = *req.FrequencySeconds
+	}
+	if req.NotifyEmail != nil {
+		m.NotifyEmail = *req.NotifyEmail
+	}
+	if req.NotifyEmailAddr != nil {
+		m.NotifyEmailAddr = nil
+		if *req.NotifyEmailAddr != "" {
+			addr := *req.NotifyEmailAddr
+			m.NotifyEmailAddr = &addr
+		}
+	}
+	if req.Active != nil {
+		m.Active = *req.Active
+	}
+	if req.TagIDs != nil {
+		m.TagIDs = slices.Clone(*req.TagIDs)
+	}
+	return m
+}
+
+// HasTag reports whether m is tagged with the tag id.
+func (m Monitor) HasTag(id uint64) bool {
+	return slices.Contains(m.TagIDs, id)
+}
+
 type ChangeEvent struct {
 	ID             uint64    `json:"id"`
 	MonitorID      uint64    `json:"monitor_id"`
@@ -30,3 +79,29 @@ type ChangeEvent struct {
 	ScreenshotDiff *string   `json:"screenshot_diff,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 }
+
+// ChangesPage is one page of a monitor's change history, newest first.
+type ChangesPage struct {
+	Items []ChangeEvent `json:"items"`
+	// NextCursor is empty once the oldest change has been returned.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total is the number of changes matching the query, or -1 if the
+	// backend did not report it.
+	Total int `json:"total"`
+}
+
+func (p *ChangesPage) UnmarshalJSON(data []byte) error {
+	// Older backends answer with a bare array and no paging metadata.
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		p.NextCursor = ""
+		p.Total = -1
+		return json.Unmarshal(trimmed, &p.Items)
+	}
+	type page ChangesPage
+	out := page{Total: -1}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*p = ChangesPage(out)
+	return nil
+}
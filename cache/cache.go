@@ -0,0 +1,305 @@
+// Package cache keeps downloaded change assets on disk so reopening a change
+// does not download its snapshots, diff and screenshots again.
+//
+// Asset bodies are stored once per content hash under blobs/, and index.json
+// maps each asset URL to its blob and the validators needed to revalidate it.
+// When the blobs grow past the size limit the least recently used URLs are
+// dropped, along with any blob no URL refers to any more.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBytes is the size limit used when none is configured.
+const DefaultMaxBytes = 256 << 20
+
+const indexFile = "index.json"
+
+// Entry describes the cached copy of one asset URL.
+type Entry struct {
+	Hash         string    `json:"hash"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+// Cache is an on-disk asset cache. All methods are safe for concurrent use.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// DefaultDir returns the asset cache directory under the user cache dir.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watcher-client", "assets"), nil
+}
+
+// Open opens or creates the cache in dir. A missing or unreadable index
+// starts the cache empty rather than failing.
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "blobs"), 0o700); err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir, maxBytes: maxBytes, entries: make(map[string]*Entry)}
+	if b, err := os.ReadFile(filepath.Join(dir, indexFile)); err == nil {
+		if err := json.Unmarshal(b, &c.entries); err != nil || c.entries == nil {
+			c.entries = make(map[string]*Entry)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeOrphans()
+	c.evict()
+	return c, c.saveIndex()
+}
+
+// Dir returns the directory the cache lives in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// MaxBytes returns the size limit of the cache.
+func (c *Cache) MaxBytes() int64 {
+	return c.maxBytes
+}
+
+// Get returns the cached body of url and its entry. A blob that went missing
+// or no longer matches its hash is dropped and reported as a miss.
+func (c *Cache) Get(url string) ([]byte, Entry, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[url]
+	if !ok {
+		c.mu.Unlock()
+		return nil, Entry{}, false
+	}
+	hash := e.Hash
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(c.blobPath(hash))
+	if err == nil && hashOf(data) != hash {
+		err = errors.New("cache: blob does not match its hash")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok = c.entries[url]
+	if !ok || e.Hash != hash {
+		return nil, Entry{}, false
+	}
+	if err != nil {
+		delete(c.entries, url)
+		c.removeBlobIfUnused(hash)
+		c.saveIndex()
+		return nil, Entry{}, false
+	}
+	return data, *e, true
+}
+
+// Touch marks url as just used, e.g. after the server confirmed it is still
+// current, and updates its validators if the server sent new ones.
+func (c *Cache) Touch(url, etag, lastModified string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[url]
+	if !ok {
+		return
+	}
+	e.LastUsed = time.Now()
+	if etag != "" {
+		e.ETag = etag
+	}
+	if lastModified != "" {
+		e.LastModified = lastModified
+	}
+	c.saveIndex()
+}
+
+// Put stores data as the current body of url and evicts old entries if the
+// cache is now over its limit.
+func (c *Cache) Put(url string, data []byte, etag, lastModified string) error {
+	hash := hashOf(data)
+	path := c.blobPath(hash)
+	// The blob is written aside and only moved into place under the lock, so
+	// that replacing another URL's entry cannot remove it between the write
+	// and adding the entry that uses it.
+	tmp, err := writeTemp(path, data, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	old, hadOld := c.entries[url]
+	c.entries[url] = &Entry{
+		Hash:         hash,
+		Size:         int64(len(data)),
+		ETag:         etag,
+		LastModified: lastModified,
+		LastUsed:     time.Now(),
+	}
+	if hadOld && old.Hash != hash {
+		c.removeBlobIfUnused(old.Hash)
+	}
+	c.evict()
+	return c.saveIndex()
+}
+
+// Usage returns the bytes stored on disk and the number of cached URLs.
+func (c *Cache) Usage() (bytes int64, urls int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalBytes(), len(c.entries)
+}
+
+// Purge removes every cached asset.
+func (c *Cache) Purge() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*Entry)
+	if err := os.RemoveAll(filepath.Join(c.dir, "blobs")); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, "blobs"), 0o700); err != nil {
+		return err
+	}
+	return c.saveIndex()
+}
+
+func (c *Cache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "blobs", hash)
+}
+
+// totalBytes counts each blob once, however many URLs share it.
+func (c *Cache) totalBytes() int64 {
+	seen := make(map[string]bool, len(c.entries))
+	var total int64
+	for _, e := range c.entries {
+		if !seen[e.Hash] {
+			seen[e.Hash] = true
+			total += e.Size
+		}
+	}
+	return total
+}
+
+// evict drops least recently used URLs until the blobs fit in maxBytes.
+func (c *Cache) evict() {
+	total := c.totalBytes()
+	if total <= c.maxBytes {
+		return
+	}
+	urls := make([]string, 0, len(c.entries))
+	for u := range c.entries {
+		urls = append(urls, u)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return c.entries[urls[i]].LastUsed.Before(c.entries[urls[j]].LastUsed)
+	})
+	for _, u := range urls {
+		if total <= c.maxBytes {
+			return
+		}
+		e := c.entries[u]
+		delete(c.entries, u)
+		if c.removeBlobIfUnused(e.Hash) {
+			total -= e.Size
+		}
+	}
+}
+
+// removeBlobIfUnused deletes the blob for hash when no entry refers to it and
+// reports whether it did.
+func (c *Cache) removeBlobIfUnused(hash string) bool {
+	for _, e := range c.entries {
+		if e.Hash == hash {
+			return false
+		}
+	}
+	os.Remove(c.blobPath(hash))
+	return true
+}
+
+// removeOrphans deletes blobs left behind by a crash between writing a blob
+// and saving the index.
+func (c *Cache) removeOrphans() {
+	used := make(map[string]bool, len(c.entries))
+	for _, e := range c.entries {
+		used[e.Hash] = true
+	}
+	files, err := os.ReadDir(filepath.Join(c.dir, "blobs"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if !used[f.Name()] {
+			os.Remove(filepath.Join(c.dir, "blobs", f.Name()))
+		}
+	}
+}
+
+func (c *Cache) saveIndex() error {
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.dir, indexFile), b, 0o600)
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	return os.Rename(tmp, path)
+}
+
+// writeTemp writes data to a new temporary file next to path and returns
+// its name, for the caller to rename to path.
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// blob returns n bytes of b.
+func blob(b byte, n int) []byte {
+	return bytes.Repeat([]byte{b}, n)
+}
+
+// put stores data under url and waits a moment so the next use of any entry
+// is strictly later.
+func put(t *testing.T, c *Cache, url string, data []byte) {
+	t.Helper()
+	if err := c.Put(url, data, "", ""); err != nil {
+		t.Fatalf("Put(%s): %v", url, err)
+	}
+	time.Sleep(2 * time.Millisecond)
+}
+
+func has(c *Cache, url string) bool {
+	_, _, ok := c.Get(url)
+	return ok
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := Open(t.TempDir(), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "a", blob('a', 10))
+	put(t, c, "b", blob('b', 10))
+	c.Touch("a", "", "")
+	time.Sleep(2 * time.Millisecond)
+	put(t, c, "c", blob('c', 10))
+
+	if has(c, "b") {
+		t.Error("b is still cached, want it evicted as the least recently used")
+	}
+	if !has(c, "a") || !has(c, "c") {
+		t.Error("a or c was evicted")
+	}
+	if bytes, urls := c.Usage(); bytes != 20 || urls != 2 {
+		t.Errorf("Usage = %d bytes, %d URLs; want 20, 2", bytes, urls)
+	}
+	if _, err := os.Stat(c.blobPath(hashOf(blob('b', 10)))); !os.IsNotExist(err) {
+		t.Errorf("blob of b still on disk: %v", err)
+	}
+}
+
+func TestSharedBlobsCountOnce(t *testing.T) {
+	c, err := Open(t.TempDir(), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := blob('x', 10)
+	put(t, c, "a", same)
+	put(t, c, "b", same)
+	put(t, c, "c", blob('c', 10))
+
+	if bytes, urls := c.Usage(); bytes != 20 || urls != 3 {
+		t.Fatalf("Usage = %d bytes, %d URLs; want 20, 3", bytes, urls)
+	}
+
+	// Dropping a alone frees nothing, so b goes too before c fits with d.
+	put(t, c, "d", blob('d', 10))
+	if has(c, "a") || has(c, "b") {
+		t.Error("a or b is still cached")
+	}
+	if !has(c, "c") || !has(c, "d") {
+		t.Error("c or d was evicted")
+	}
+}
+
+func TestOpenEvictsToNewLimit(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "a", blob('a', 10))
+	put(t, c, "b", blob('b', 10))
+	put(t, c, "c", blob('c', 10))
+
+	c, err = Open(dir, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes, urls := c.Usage(); bytes != 10 || urls != 1 || !has(c, "c") {
+		t.Errorf("Usage = %d bytes, %d URLs; want only c left", bytes, urls)
+	}
+}
+
+func TestGetDropsCorruptBlob(t *testing.T) {
+	c, err := Open(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := blob('a', 10)
+	put(t, c, "a", data)
+	if err := os.WriteFile(c.blobPath(hashOf(data)), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if has(c, "a") {
+		t.Fatal("Get returned a blob that does not match its hash")
+	}
+	if _, urls := c.Usage(); urls != 0 {
+		t.Errorf("corrupt entry still indexed")
+	}
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"watcher-client/api"
+)
+
+// assetTimeout bounds downloading the text diff of a change.
+const assetTimeout = 45 * time.Second
+
+func changesList(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "changes list")
+	limit := fs.Int("limit", api.DefaultChangesPageSize, "number of changes to show")
+	all := fs.Bool("all", false, "page through the whole history")
+	before := fs.String("before", "", "only changes before this time (RFC 3339)")
+	after := fs.String("after", "", "only changes after this time (RFC 3339)")
+	format := addFormatFlag(fs)
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return fmt.Errorf("%w: exactly one monitor ID is required", errUsage)
+	}
+	monitorID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+	q := api.ChangesQuery{Limit: *limit}
+	if q.Before, err = parseTimeFlag("before", *before); err != nil {
+		return err
+	}
+	if q.After, err = parseTimeFlag("after", *after); err != nil {
+		return err
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+
+	var changes []api.ChangeEvent
+	for {
+		page, err := client.ListChangesPage(ctx, monitorID, q)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, page.Items...)
+		if !*all || page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	if *format == formatJSON {
+		return writeJSON(env.Stdout, changes)
+	}
+	rows := make([][]string, 0, len(changes))
+	for _, c := range changes {
+		rows = append(rows, []string{
+			strconv.FormatUint(c.ID, 10),
+			formatTime(c.CreatedAt),
+			statusChange(c),
+			yesNo(c.HTMLDiff != nil && *c.HTMLDiff != ""),
+			yesNo(c.ScreenshotDiff != nil && *c.ScreenshotDiff != ""),
+		})
+	}
+	return writeTable(env.Stdout, []string{"ID", "DETECTED", "HTTP", "TEXT DIFF", "SCREENSHOT"}, rows)
+}
+
+func changesShow(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "changes show")
+	withDiff := fs.Bool("diff", false, "download and print the text diff")
+	format := addFormatFlag(fs)
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if len(pos) != 2 {
+		return fmt.Errorf("%w: a monitor ID and a change ID are required", errUsage)
+	}
+	monitorID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+	changeID, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+	c, err := findChange(ctx, client, monitorID, changeID)
+	if err != nil {
+		return err
+	}
+
+	var segments []api.DiffSegment
+	if *withDiff && c.HTMLDiff != nil && *c.HTMLDiff != "" {
+		// FetchAsset has no timeout of its own.
+		actx, cancel := context.WithTimeout(ctx, assetTimeout)
+		data, err := api.FetchAsset(actx, *c.HTMLDiff)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("download diff: %w", err)
+		}
+		if segments, err = api.DecodeDiff(data); err != nil {
+			return fmt.Errorf("decode diff: %w", err)
+		}
+	}
+
+	if *format == formatJSON {
+		out := struct {
+			*api.ChangeEvent
+			Diff []api.DiffSegment `json:"diff,omitempty"`
+		}{c, segments}
+		return writeJSON(env.Stdout, out)
+	}
+
+	rows := [][]string{
+		{"ID", strconv.FormatUint(c.ID, 10)},
+		{"Monitor", strconv.FormatUint(c.MonitorID, 10)},
+		{"Run", strconv.FormatUint(c.RunID, 10)},
+		{"Detected", formatTime(c.CreatedAt)},
+		{"HTTP status", statusChange(*c)},
+		{"Previous HTML", orDash(c.HTMLPrev)},
+		{"Current HTML", orDash(c.HTMLCurr)},
+		{"Text diff", orDash(c.HTMLDiff)},
+		{"Previous screenshot", orDash(c.ScreenshotPrev)},
+		{"Current screenshot", orDash(c.ScreenshotCurr)},
+		{"Screenshot diff", orDash(c.ScreenshotDiff)},
+	}
+	if err := writeTable(env.Stdout, []string{"FIELD", "VALUE"}, rows); err != nil {
+		return err
+	}
+	if *withDiff {
+		fmt.Fprintln(env.Stdout)
+		fmt.Fprintln(env.Stdout, renderDiff(segments))
+	}
+	return nil
+}
+
+// findChange pages back through a monitor's history until it finds changeID.
+func findChange(ctx context.Context, client *api.Client, monitorID, changeID uint64) (*api.ChangeEvent, error) {
+	q := api.ChangesQuery{Limit: 200}
+	for {
+		page, err := client.ListChangesPage(ctx, monitorID, q)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.Items {
+			if page.Items[i].ID == changeID {
+				return &page.Items[i], nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil, fmt.Errorf("change %d not found for monitor %d", changeID, monitorID)
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// renderDiff marks insertions as {+text+}, deletions as [-text-] and
+// replacements as {~text~}, in the style of git's word diff.
+func renderDiff(segments []api.DiffSegment) string {
+	if len(segments) == 0 {
+		return "(no diff available)"
+	}
+	var b strings.Builder
+	for _, seg := range segments {
+		switch seg.Kind {
+		case api.DiffInserted:
+			b.WriteString("{+" + seg.Text + "+}")
+		case api.DiffDeleted:
+			b.WriteString("[-" + seg.Text + "-]")
+		case api.DiffReplaced:
+			b.WriteString("{~" + seg.Text + "~}")
+		default:
+			b.WriteString(seg.Text)
+		}
+	}
+	return b.String()
+}
+
+func statusChange(c api.ChangeEvent) string {
+	if c.HTTPStatusPrev == nil && c.HTTPStatusCurr == nil {
+		return "-"
+	}
+	status := func(p *int) string {
+		if p == nil {
+			return "?"
+		}
+		return strconv.Itoa(*p)
+	}
+	if c.HTTPStatusPrev != nil && c.HTTPStatusCurr != nil && *c.HTTPStatusPrev == *c.HTTPStatusCurr {
+		return status(c.HTTPStatusCurr)
+	}
+	return status(c.HTTPStatusPrev) + "→" + status(c.HTTPStatusCurr)
+}
+
+func parseTimeFlag(name, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: -%s must be an RFC 3339 time", errUsage, name)
+	}
+	return t, nil
+}
@@ -0,0 +1,247 @@
+// Package cli implements the headless command-line mode:
+//
+//	watcher-client [-profile NAME] [-backend-url URL] monitors list|add|edit|delete
+//	watcher-client changes list|show
+//	watcher-client instance register
+//
+// It shares the api and config packages with the GUI and never opens a window.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"watcher-client/api"
+	"watcher-client/config"
+)
+
+// Env carries the process-level inputs of a CLI invocation.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Profile and BackendURL come from the global -profile / -backend-url
+	// flags and override WATCHER_PROFILE / WATCHER_BACKEND_URL.
+	Profile    string
+	BackendURL string
+}
+
+// errUsage marks errors that should be followed by the command's usage.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, env *Env, args []string) error
+}
+
+var commands = map[string][]command{
+	"monitors": {
+		{"list", "monitors list [-o table|json]", "List monitors", monitorsList},
+		{"add", "monitors add -url URL [-name N] [-selector CSS] [-frequency SECS] [-notify-email ADDR] [-o table|json]", "Create a monitor", monitorsAdd},
+		{"edit", "monitors edit ID [-name N] [-url URL] [-selector CSS] [-frequency SECS] [-notify-email ADDR] [-notify=BOOL] [-active=BOOL] [-o table|json]", "Change fields of a monitor", monitorsEdit},
+		{"delete", "monitors delete ID...", "Delete monitors", monitorsDelete},
+	},
+	"changes": {
+		{"list", "changes list MONITOR_ID [-limit N] [-all] [-before RFC3339] [-after RFC3339] [-o table|json]", "List detected changes", changesList},
+		{"show", "changes show MONITOR_ID CHANGE_ID [-diff] [-o table|json]", "Show one change, optionally with its text diff", changesShow},
+	},
+	"instance": {
+		{"register", "instance register [-name N]", "Register this machine with the profile's backend", instanceRegister},
+	},
+}
+
+// IsCommand reports whether name starts a CLI invocation rather than the GUI.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes a CLI command and returns the process exit code.
+func Run(args []string, env Env) int {
+	if env.Stdin == nil {
+		env.Stdin = os.Stdin
+	}
+	if len(args) == 0 || !IsCommand(args[0]) {
+		printUsage(env.Stderr)
+		return 2
+	}
+	group := commands[args[0]]
+	if len(args) < 2 {
+		printGroupUsage(env.Stderr, group)
+		return 2
+	}
+	for _, cmd := range group {
+		if cmd.name != args[1] {
+			continue
+		}
+		err := cmd.run(context.Background(), &env, args[2:])
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(env.Stderr, "%v\nusage: watcher-client %s\n", err, cmd.usage)
+			return 2
+		default:
+			fmt.Fprintf(env.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	printGroupUsage(env.Stderr, group)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: watcher-client [-profile NAME] [-backend-url URL] <command> <subcommand> [flags]")
+	fmt.Fprintln(w, "Without a command the desktop UI starts.")
+	for _, group := range []string{"monitors", "changes", "instance"} {
+		printGroupUsage(w, commands[group])
+	}
+}
+
+func printGroupUsage(w io.Writer, group []command) {
+	fmt.Fprintln(w)
+	for _, cmd := range group {
+		words := strings.Fields(cmd.usage)
+		fmt.Fprintf(w, "  %-18s %s\n", strings.Join(words[:2], " "), cmd.summary)
+	}
+}
+
+func newFlagSet(env *Env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	return fs
+}
+
+// parseInterspersed parses flags that may appear after positional
+// arguments (e.g. "monitors edit 12 -active=false") and returns the positionals.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// session is the loaded config and selected profile for a command.
+type session struct {
+	cfg     *config.InstanceConfig
+	name    string
+	profile *config.Profile
+}
+
+func openSession(env *Env) (*session, error) {
+	store, err := config.OpenSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	if fs, ok := store.(*config.FileStore); ok && fs.Locked() {
+		pass, err := readPassphrase(env, !fs.Exists())
+		if err != nil {
+			return nil, err
+		}
+		if err := fs.Unlock(pass); err != nil {
+			return nil, err
+		}
+	}
+	config.SetSecretStore(store)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	name := firstNonEmpty(env.Profile, os.Getenv("WATCHER_PROFILE"), cfg.ActiveProfile, config.DefaultProfile)
+	s := &session{cfg: cfg, name: name, profile: cfg.Profiles[name]}
+	if u := firstNonEmpty(env.BackendURL, os.Getenv("WATCHER_BACKEND_URL")); u != "" {
+		if s.profile == nil {
+			s.profile = &config.Profile{}
+		}
+		s.profile.BackendURL = u
+	}
+	return s, nil
+}
+
+func (s *session) client() (*api.Client, error) {
+	if s.profile != nil && s.profile.SecretMissing {
+		return nil, fmt.Errorf("the instance secret of profile %q is missing from the secret store; run 'watcher-client -profile %s instance register' or the desktop setup again", s.name, s.name)
+	}
+	if s.profile == nil || !s.profile.HasCredentials() {
+		return nil, fmt.Errorf("profile %q is not registered; run 'watcher-client -profile %s instance register' or the desktop setup first", s.name, s.name)
+	}
+	return api.NewClient(s.profile.BackendURL, s.profile.InstanceKey, s.profile.InstanceSecret), nil
+}
+
+// readPassphrase takes the secret file passphrase from WATCHER_PASSPHRASE or,
+// failing that, asks for it. On a terminal it is not echoed and, when the
+// file is being created, asked for twice so that a typo cannot lock the user
+// out. Otherwise a line is read from stdin.
+func readPassphrase(env *Env, creating bool) (string, error) {
+	if pass := os.Getenv("WATCHER_PASSPHRASE"); pass != "" {
+		return pass, nil
+	}
+	prompt := "Secret file passphrase: "
+	if creating {
+		prompt = "Choose a passphrase for the secret file: "
+	}
+
+	f, ok := env.Stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(env.Stderr, prompt)
+		line, err := bufio.NewReader(env.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("no passphrase given; set WATCHER_PASSPHRASE")
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	pass, err := readHidden(env, int(f.Fd()), prompt)
+	if err != nil || !creating {
+		return pass, err
+	}
+	again, err := readHidden(env, int(f.Fd()), "Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != pass {
+		return "", errors.New("the passphrases do not match")
+	}
+	return pass, nil
+}
+
+// readHidden reads a line from the terminal fd without echoing it.
+func readHidden(env *Env, fd int, prompt string) (string, error) {
+	fmt.Fprint(env.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(env.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	return string(b), nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"watcher-client/api"
+	"watcher-client/config"
+)
+
+func instanceRegister(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "instance register")
+	name := fs.String("name", "", "instance name shown on the backend (defaults to the hostname)")
+	force := fs.Bool("force", false, "replace existing credentials for the profile")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+
+	s, err := openSession(env)
+	if err != nil {
+		return err
+	}
+	if s.profile != nil && s.profile.HasCredentials() && !*force {
+		return fmt.Errorf("profile %q is already registered; pass -force to replace its credentials", s.name)
+	}
+	backendURL := config.DefaultBackendURL
+	if s.profile != nil && s.profile.BackendURL != "" {
+		backendURL = s.profile.BackendURL
+	}
+
+	instanceName := *name
+	if instanceName == "" {
+		if instanceName, err = os.Hostname(); err != nil || instanceName == "" {
+			instanceName = "watcher-device"
+		}
+	}
+
+	key, secret, err := api.RegisterInstanceContext(ctx, backendURL, instanceName)
+	if err != nil {
+		return err
+	}
+
+	p := s.cfg.Profile(s.name)
+	p.BackendURL = backendURL
+	p.InstanceKey = key
+	p.InstanceSecret = secret
+	if s.cfg.ActiveProfile == "" {
+		s.cfg.ActiveProfile = s.name
+	}
+	if err := config.Save(s.cfg); err != nil {
+		return fmt.Errorf("registered as %s but saving the config failed: %w", key, err)
+	}
+	fmt.Fprintf(env.Stdout, "registered %q with %s as profile %q (key %s)\n", instanceName, backendURL, s.name, key)
+	return nil
+}
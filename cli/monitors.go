@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"watcher-client/api"
+)
+
+func monitorRows(ms []api.Monitor) [][]string {
+	rows := make([][]string, 0, len(ms))
+	for _, m := range ms {
+		rows = append(rows, []string{
+			strconv.FormatUint(m.ID, 10),
+			m.Name,
+			m.URL,
+			orDash(m.CSSSelector),
+			strconv.Itoa(m.FrequencySeconds),
+			yesNo(m.Active),
+			orDash(m.LastStatus),
+			orDash(m.NotifyEmailAddr),
+		})
+	}
+	return rows
+}
+
+var monitorHeader = []string{"ID", "NAME", "URL", "SELECTOR", "FREQ(S)", "ACTIVE", "STATUS", "NOTIFY"}
+
+func printMonitors(env *Env, format string, ms []api.Monitor) error {
+	if format == formatJSON {
+		return writeJSON(env.Stdout, ms)
+	}
+	return writeTable(env.Stdout, monitorHeader, monitorRows(ms))
+}
+
+func monitorsList(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "monitors list")
+	format := addFormatFlag(fs)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+	ms, err := client.ListMonitorsContext(ctx)
+	if err != nil {
+		return err
+	}
+	return printMonitors(env, *format, ms)
+}
+
+func monitorsAdd(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "monitors add")
+	name := fs.String("name", "", "display name (defaults to the URL)")
+	url := fs.String("url", "", "page to watch (required)")
+	selector := fs.String("selector", "", "CSS selector to restrict the comparison to")
+	freq := fs.Int("frequency", 300, "check frequency in seconds")
+	notifyAddr := fs.String("notify-email", "", "send change emails to this address")
+	format := addFormatFlag(fs)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *url == "" {
+		return fmt.Errorf("%w: -url is required", errUsage)
+	}
+	if *freq <= 0 {
+		return fmt.Errorf("%w: -frequency must be positive", errUsage)
+	}
+
+	req := api.CreateMonitorReq{
+		Name:             *name,
+		URL:              *url,
+		FrequencySeconds: *freq,
+		NotifyEmail:      *notifyAddr != "",
+		NotifyEmailAddr:  *notifyAddr,
+	}
+	if req.Name == "" {
+		req.Name = req.URL
+	}
+	if *selector != "" {
+		req.CSSSelector = selector
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+	m, err := client.CreateMonitorContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printMonitors(env, *format, []api.Monitor{*m})
+}
+
+func monitorsEdit(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "monitors edit")
+	name := fs.String("name", "", "new display name")
+	url := fs.String("url", "", "new URL")
+	selector := fs.String("selector", "", "new CSS selector (empty clears it)")
+	freq := fs.Int("frequency", 0, "new check frequency in seconds")
+	notifyAddr := fs.String("notify-email", "", "new notification address (empty clears it)")
+	notify := fs.Bool("notify", false, "enable or disable email notifications")
+	active := fs.Bool("active", false, "resume (true) or pause (false) the monitor")
+	format := addFormatFlag(fs)
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return fmt.Errorf("%w: exactly one monitor ID is required", errUsage)
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	// Only flags given on the command line end up in the patch.
+	var req api.UpdateMonitorReq
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			req.Name = name
+		case "url":
+			req.URL = url
+		case "selector":
+			req.CSSSelector = selector
+		case "frequency":
+			req.FrequencySeconds = freq
+		case "notify-email":
+			req.NotifyEmailAddr = notifyAddr
+		case "notify":
+			req.NotifyEmail = notify
+		case "active":
+			req.Active = active
+		}
+	})
+	if req.IsEmpty() {
+		return fmt.Errorf("%w: nothing to change", errUsage)
+	}
+	if req.FrequencySeconds != nil && *req.FrequencySeconds <= 0 {
+		return fmt.Errorf("%w: -frequency must be positive", errUsage)
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+	m, err := client.UpdateMonitorContext(ctx, id, req)
+	if err != nil {
+		return err
+	}
+	return printMonitors(env, *format, []api.Monitor{*m})
+}
+
+func monitorsDelete(ctx context.Context, env *Env, args []string) error {
+	fs := newFlagSet(env, "monitors delete")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return fmt.Errorf("%w: at least one monitor ID is required", errUsage)
+	}
+	ids := make([]uint64, 0, len(pos))
+	for _, p := range pos {
+		id, err := parseID(p)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	client, err := sessionClient(env)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := client.DeleteMonitorContext(ctx, id); err != nil {
+			return fmt.Errorf("delete %d: %w", id, err)
+		}
+		fmt.Fprintf(env.Stdout, "deleted %d\n", id)
+	}
+	return nil
+}
+
+func sessionClient(env *Env) (*api.Client, error) {
+	s, err := openSession(env)
+	if err != nil {
+		return nil, err
+	}
+	return s.client()
+}
+
+func parseID(s string) (uint64, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid ID %q", errUsage, s)
+	}
+	return id, nil
+}
@@ -2,22 +2,162 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"time"
 )
 
+const (
+	// DefaultProfile is the profile used when none has been selected.
+	DefaultProfile = "default"
+	// DefaultBackendURL is offered when setting up a new profile.
+	DefaultBackendURL = "http://localhost:8080"
+	// DefaultSyncInterval is how often the client reloads in the background
+	// when no interval has been configured.
+	DefaultSyncInterval = 5 * time.Minute
+)
+
+// Profile holds the connection settings and credentials for one backend.
+type Profile struct {
+	BackendURL  string `json:"backend_url"`
+	InstanceKey string `json:"instance_key"`
+	// InstanceSecret is only written to the config file when no SecretStore
+	// is in use.
+	InstanceSecret string `json:"instance_secret,omitempty"`
+	// NotifyMonitors lists the monitors whose new changes raise a desktop
+	// notification on this device.
+	NotifyMonitors []uint64 `json:"notify_monitors,omitempty"`
+	// SecretMissing is set by Load when the profile has an instance key but
+	// its secret is gone from the secret store, e.g. a deleted keyring item.
+	// The profile then has no credentials until it is registered or linked
+	// again.
+	SecretMissing bool `json:"-"`
+}
+
+// HasCredentials reports whether the profile has been registered with its backend.
+func (p *Profile) HasCredentials() bool {
+	return p.InstanceKey != "" && p.InstanceSecret != ""
+}
+
+// Notifies reports whether desktop notifications are on for a monitor.
+func (p *Profile) Notifies(monitorID uint64) bool {
+	return slices.Contains(p.NotifyMonitors, monitorID)
+}
+
+// SetNotifies turns desktop notifications for a monitor on or off.
+func (p *Profile) SetNotifies(monitorID uint64, on bool) {
+	p.NotifyMonitors = slices.DeleteFunc(p.NotifyMonitors, func(id uint64) bool { return id == monitorID })
+	if on {
+		p.NotifyMonitors = append(p.NotifyMonitors, monitorID)
+	}
+}
+
 type InstanceConfig struct {
-	BackendURL     string `json:"backend_url"`
-	InstanceKey    string `json:"instance_key"`
-	InstanceSecret string `json:"instance_secret"`
+	// SchemaVersion is the file layout version; Save always writes
+	// CurrentSchemaVersion.
+	SchemaVersion int    `json:"schema_version"`
+	ActiveProfile string `json:"active_profile"`
+	// SecretStore is the Kind of the store holding the profiles' secrets, or
+	// empty when they are kept inline in this file.
+	SecretStore string              `json:"secret_store,omitempty"`
+	Profiles    map[string]*Profile `json:"profiles"`
+	// RunInBackground keeps the client running in the system tray when the
+	// main window is closed.
+	RunInBackground bool `json:"run_in_background,omitempty"`
+	// SyncIntervalSeconds is how often monitors are reloaded in the
+	// background; 0 means DefaultSyncInterval.
+	SyncIntervalSeconds int `json:"sync_interval_seconds,omitempty"`
+	// ColumnWidths holds the monitor table's column widths by column key,
+	// as last resized by the user.
+	ColumnWidths map[string]float32 `json:"column_widths,omitempty"`
+	// MonitorView is how the monitor table was last searched, filtered and
+	// sorted.
+	MonitorView MonitorView `json:"monitor_view"`
 }
 
-func configPath() (string, error) {
+// MonitorView holds the search, filters and sort order of the monitor table.
+type MonitorView struct {
+	Query string `json:"query,omitempty"`
+	// Status is "active", "inactive", "error" or empty for any.
+	Status string `json:"status,omitempty"`
+	// NotifyEmail is "on", "off" or empty for any.
+	NotifyEmail string `json:"notify_email,omitempty"`
+	// Sort is the key of the column or field the table is sorted by, or
+	// empty for the order the backend returns.
+	Sort     string `json:"sort,omitempty"`
+	SortDesc bool   `json:"sort_desc,omitempty"`
+	// GroupByTag lists monitors under a header for each of their tags.
+	GroupByTag bool `json:"group_by_tag,omitempty"`
+}
+
+// SyncInterval returns the configured background sync interval.
+func (c *InstanceConfig) SyncInterval() time.Duration {
+	if c.SyncIntervalSeconds <= 0 {
+		return DefaultSyncInterval
+	}
+	return time.Duration(c.SyncIntervalSeconds) * time.Second
+}
+
+var secretStore SecretStore
+
+// SetSecretStore selects where Load and Save keep instance secrets. With a
+// nil store secrets are written to the config file in plaintext.
+func SetSecretStore(s SecretStore) {
+	secretStore = s
+}
+
+// Profile returns the named profile, creating an empty one if it does not exist.
+func (c *InstanceConfig) Profile(name string) *Profile {
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		p = &Profile{}
+		c.Profiles[name] = p
+	}
+	return p
+}
+
+// ProfileNames returns the configured profile names in sorted order.
+func (c *InstanceConfig) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var dirOverride string
+
+// SetDir makes Load, Save and the secret file use dir instead of the user
+// config directory. Demo mode uses it to stay away from the real config.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
+func configDir() (string, error) {
+	if dirOverride != "" {
+		return dirOverride, nil
+	}
 	dir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dir, "watcher-client", "config.json"), nil
+	return filepath.Join(dir, "watcher-client"), nil
+}
+
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
 }
 
 func Load() (*InstanceConfig, error) {
@@ -32,13 +172,69 @@ func Load() (*InstanceConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	migrated, fromVersion, err := migrate(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	var cfg InstanceConfig
-	if err := json.Unmarshal(b, &cfg); err != nil {
+	if err := json.Unmarshal(migrated, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	moveSecrets, err := loadSecrets(&cfg)
+	if err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if fromVersion != CurrentSchemaVersion || moveSecrets {
+		// Moving secrets into the store changes nothing else worth keeping
+		// a backup of.
+		if fromVersion != CurrentSchemaVersion {
+			if err := backupConfig(path, b, fromVersion); err != nil {
+				return nil, fmt.Errorf("back up config before migration: %w", err)
+			}
+		}
+		if err := Save(&cfg); err != nil {
+			return nil, fmt.Errorf("save migrated config: %w", err)
+		}
+	}
 	return &cfg, nil
 }
 
+// loadSecrets fills in profile secrets from the secret store. It reports
+// whether plaintext secrets were found that Save should move into the store.
+func loadSecrets(cfg *InstanceConfig) (bool, error) {
+	if cfg.SecretStore == "" {
+		if secretStore == nil {
+			return false, nil
+		}
+		for _, p := range cfg.Profiles {
+			if p.InstanceSecret != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if secretStore == nil || secretStore.Kind() != cfg.SecretStore {
+		return false, fmt.Errorf("instance secrets are kept in the %s store, which is not available", cfg.SecretStore)
+	}
+	for name, p := range cfg.Profiles {
+		secret, err := secretStore.Get(name)
+		if errors.Is(err, ErrSecretNotFound) {
+			p.SecretMissing = p.InstanceKey != ""
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("load secret for profile %q: %w", name, err)
+		}
+		p.InstanceSecret = secret
+	}
+	return false, nil
+}
+
 func Save(cfg *InstanceConfig) error {
 	path, err := configPath()
 	if err != nil {
@@ -47,9 +243,82 @@ func Save(cfg *InstanceConfig) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	b, err := json.MarshalIndent(cfg, "", "  ")
+	cfg.SchemaVersion = CurrentSchemaVersion
+	out := *cfg
+	out.SecretStore = ""
+	if secretStore != nil {
+		// Secrets go to the store first so the file never references a
+		// secret that was not persisted.
+		out.SecretStore = secretStore.Kind()
+		out.Profiles = make(map[string]*Profile, len(cfg.Profiles))
+		for name, p := range cfg.Profiles {
+			if p.InstanceSecret != "" {
+				if err := secretStore.Set(name, p.InstanceSecret); err != nil {
+					return fmt.Errorf("store secret for profile %q: %w", name, err)
+				}
+			}
+			stripped := *p
+			stripped.InstanceSecret = ""
+			out.Profiles[name] = &stripped
+		}
+	}
+	cfg.SecretStore = out.SecretStore
+
+	b, err := json.MarshalIndent(&out, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0o600)
+	if err := writeFileAtomic(path, b, 0o600); err != nil {
+		return err
+	}
+	if secretStore != nil {
+		return pruneSecrets(cfg)
+	}
+	return nil
+}
+
+// pruneSecrets deletes the stored secrets of profiles that were removed or
+// renamed, or that no longer have credentials. It runs after the config file
+// was written, so the file never references a deleted secret.
+func pruneSecrets(cfg *InstanceConfig) error {
+	names, err := secretStore.Profiles()
+	if err != nil {
+		return fmt.Errorf("list stored secrets: %w", err)
+	}
+	for _, name := range names {
+		if p := cfg.Profiles[name]; p != nil && p.InstanceSecret != "" {
+			continue
+		}
+		if err := secretStore.Delete(name); err != nil {
+			return fmt.Errorf("delete secret of profile %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path via a temp file and rename so a crash never
+// leaves a half-written config (and a lost instance secret) behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSaveDeletesSecretsOfRemovedProfiles(t *testing.T) {
+	fileStore := func(t *testing.T) SecretStore {
+		fs := NewFileStore(filepath.Join(t.TempDir(), "secrets.enc"))
+		if err := fs.Unlock("passphrase"); err != nil {
+			t.Fatal(err)
+		}
+		return fs
+	}
+	for name, open := range map[string]func(t *testing.T) SecretStore{
+		"memory": func(*testing.T) SecretStore { return memStore{} },
+		"file":   fileStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			useDir(t, store, "")
+			cfg := &InstanceConfig{
+				ActiveProfile: "home",
+				Profiles: map[string]*Profile{
+					"home":  {BackendURL: "http://localhost:8080", InstanceKey: "k1", InstanceSecret: "s1"},
+					"work":  {BackendURL: "http://localhost:8081", InstanceKey: "k2", InstanceSecret: "s2"},
+					"spare": {BackendURL: "http://localhost:8082", InstanceKey: "k3", InstanceSecret: "s3"},
+				},
+			}
+			if err := Save(cfg); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			// Rename work, drop spare and rotate home.
+			cfg.Profiles["office"] = cfg.Profiles["work"]
+			delete(cfg.Profiles, "work")
+			delete(cfg.Profiles, "spare")
+			cfg.Profiles["home"].InstanceSecret = "s1b"
+			if err := Save(cfg); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			got, err := store.Profiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if want := []string{"home", "office"}; !slices.Equal(got, want) {
+				t.Errorf("stored secrets for %q, want %q", got, want)
+			}
+			if s, _ := store.Get("home"); s != "s1b" {
+				t.Errorf("home secret = %q, want the rotated one", s)
+			}
+
+			// Clearing the credentials of a profile drops its secret too.
+			cfg.Profiles["office"].InstanceKey, cfg.Profiles["office"].InstanceSecret = "", ""
+			if err := Save(cfg); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if _, err := store.Get("office"); err != ErrSecretNotFound {
+				t.Errorf("office secret still stored: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || freebsd || openbsd || netbsd)
+
+package config
+
+import "errors"
+
+// NewKeyringStore is only implemented for the freedesktop Secret Service;
+// other platforms fall back to the encrypted secret file.
+func NewKeyringStore() (SecretStore, error) {
+	return nil, errors.New("keyring: not supported on this platform")
+}
@@ -0,0 +1,214 @@
+//go:build linux || freebsd || openbsd || netbsd
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName       = "org.freedesktop.secrets"
+	secretServicePath       = "/org/freedesktop/secrets"
+	secretServiceIface      = "org.freedesktop.Secret.Service"
+	secretCollectionIface   = "org.freedesktop.Secret.Collection"
+	secretItemIface         = "org.freedesktop.Secret.Item"
+	secretPromptIface       = "org.freedesktop.Secret.Prompt"
+	secretDefaultCollection = "/org/freedesktop/secrets/aliases/default"
+
+	keyringStoreKind = "keyring"
+	keyringAppAttr   = "watcher-client"
+)
+
+// secretValue mirrors the Secret Service (oayays) Secret struct.
+type secretValue struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// KeyringStore keeps secrets in the desktop keyring (GNOME Keyring, KWallet,
+// KeePassXC…) through the freedesktop Secret Service D-Bus API.
+type KeyringStore struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+}
+
+// NewKeyringStore connects to the Secret Service and fails if none is running.
+func NewKeyringStore() (SecretStore, error) {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The "plain" algorithm is acceptable here: the session bus is local to
+	// the user's login session.
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = conn.Object(secretServiceName, secretServicePath).
+		Call(secretServiceIface+".OpenSession", 0, "plain", dbus.MakeVariant("")).
+		Store(&output, &session)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("secret service: %w", err)
+	}
+	return &KeyringStore{conn: conn, session: session}, nil
+}
+
+func (s *KeyringStore) Kind() string { return keyringStoreKind }
+
+func keyringAttrs(profile string) map[string]string {
+	return map[string]string{
+		"application": keyringAppAttr,
+		"profile":     profile,
+	}
+}
+
+func (s *KeyringStore) find(profile string) (dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := s.conn.Object(secretServiceName, secretServicePath).
+		Call(secretServiceIface+".SearchItems", 0, keyringAttrs(profile)).
+		Store(&unlocked, &locked)
+	if err != nil {
+		return "", err
+	}
+	if len(unlocked) > 0 {
+		return unlocked[0], nil
+	}
+	if len(locked) == 0 {
+		return "", ErrSecretNotFound
+	}
+	if err := s.unlock(locked[:1]); err != nil {
+		return "", err
+	}
+	return locked[0], nil
+}
+
+func (s *KeyringStore) unlock(paths []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err := s.conn.Object(secretServiceName, secretServicePath).
+		Call(secretServiceIface+".Unlock", 0, paths).
+		Store(&unlocked, &prompt)
+	if err != nil {
+		return err
+	}
+	return s.prompt(prompt)
+}
+
+// prompt runs a Secret Service prompt (e.g. the keyring unlock dialog) and
+// waits for the user to complete or dismiss it.
+func (s *KeyringStore) prompt(path dbus.ObjectPath) error {
+	if path == "" || path == "/" {
+		return nil
+	}
+	if err := s.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(secretPromptIface),
+		dbus.WithMatchMember("Completed"),
+	); err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 1)
+	s.conn.Signal(signals)
+	defer s.conn.RemoveSignal(signals)
+
+	if err := s.conn.Object(secretServiceName, path).Call(secretPromptIface+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+	for sig := range signals {
+		if sig.Path != path || len(sig.Body) == 0 {
+			continue
+		}
+		if dismissed, _ := sig.Body[0].(bool); dismissed {
+			return errors.New("secret service: prompt dismissed")
+		}
+		return nil
+	}
+	return errors.New("secret service: connection closed")
+}
+
+func (s *KeyringStore) Get(profile string) (string, error) {
+	item, err := s.find(profile)
+	if err != nil {
+		return "", err
+	}
+	var secret secretValue
+	err = s.conn.Object(secretServiceName, item).
+		Call(secretItemIface+".GetSecret", 0, s.session).
+		Store(&secret)
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Value), nil
+}
+
+func (s *KeyringStore) Set(profile, secret string) error {
+	props := map[string]dbus.Variant{
+		secretItemIface + ".Label":      dbus.MakeVariant("Watcher client (" + profile + ")"),
+		secretItemIface + ".Attributes": dbus.MakeVariant(keyringAttrs(profile)),
+	}
+	value := secretValue{
+		Session:     s.session,
+		Value:       []byte(secret),
+		ContentType: "text/plain",
+	}
+
+	var item, prompt dbus.ObjectPath
+	err := s.conn.Object(secretServiceName, secretDefaultCollection).
+		Call(secretCollectionIface+".CreateItem", 0, props, value, true).
+		Store(&item, &prompt)
+	if err != nil {
+		return err
+	}
+	return s.prompt(prompt)
+}
+
+func (s *KeyringStore) Delete(profile string) error {
+	item, err := s.find(profile)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var prompt dbus.ObjectPath
+	if err := s.conn.Object(secretServiceName, item).Call(secretItemIface+".Delete", 0).Store(&prompt); err != nil {
+		return err
+	}
+	return s.prompt(prompt)
+}
+
+// Profiles lists the profiles with an item in the keyring, locked or not.
+// Item attributes can be read without unlocking the item.
+func (s *KeyringStore) Profiles() ([]string, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := s.conn.Object(secretServiceName, secretServicePath).
+		Call(secretServiceIface+".SearchItems", 0, map[string]string{"application": keyringAppAttr}).
+		Store(&unlocked, &locked)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, item := range append(unlocked, locked...) {
+		v, err := s.conn.Object(secretServiceName, item).GetProperty(secretItemIface + ".Attributes")
+		if err != nil {
+			return nil, err
+		}
+		if attrs, ok := v.Value().(map[string]string); ok && attrs["profile"] != "" {
+			profiles = append(profiles, attrs["profile"])
+		}
+	}
+	return profiles, nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// CurrentSchemaVersion is the config layout written by Save. Bump it together
+// with a new entry in migrations whenever the file format changes.
+const CurrentSchemaVersion = 1
+
+// migration upgrades a decoded config file by exactly one schema version.
+type migration func(raw map[string]any) error
+
+// migrations[i] upgrades a file from version i to i+1.
+var migrations = []migration{
+	migrateV0ToV1,
+}
+
+// migrateV0ToV1 moves the single-backend fields of unversioned files into the
+// default profile. Unversioned files that already use profiles are unchanged.
+func migrateV0ToV1(raw map[string]any) error {
+	if _, ok := raw["profiles"]; ok {
+		return nil
+	}
+	p := map[string]any{}
+	for _, field := range []string{"backend_url", "instance_key", "instance_secret"} {
+		if v, ok := raw[field]; ok {
+			p[field] = v
+			delete(raw, field)
+		}
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	raw["profiles"] = map[string]any{DefaultProfile: p}
+	if _, ok := raw["active_profile"]; !ok {
+		raw["active_profile"] = DefaultProfile
+	}
+	return nil
+}
+
+// migrate brings a config file up to CurrentSchemaVersion. It returns the
+// upgraded JSON and the version the file was at.
+func migrate(b []byte) ([]byte, int, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if v, ok := raw["schema_version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, 0, fmt.Errorf("invalid schema_version %v", v)
+		}
+		version = int(f)
+	}
+	if version > CurrentSchemaVersion {
+		return nil, version, fmt.Errorf("config schema version %d is newer than this client supports (%d); please upgrade", version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return b, version, nil
+	}
+
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return nil, version, fmt.Errorf("migrate config from v%d to v%d: %w", v, v+1, err)
+		}
+	}
+	raw["schema_version"] = CurrentSchemaVersion
+
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return nil, version, err
+	}
+	return out, version, nil
+}
+
+// backupConfig copies the file about to be rewritten by a migration next to
+// it, e.g. config.json.v0.bak. An existing backup is never overwritten. With
+// a secret store in use the secrets are left out, since the migration moves
+// them into the store and a plaintext copy must not stay behind.
+func backupConfig(path string, original []byte, version int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	if secretStore != nil {
+		var err error
+		if original, err = stripSecrets(original); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(backup, original, 0o600)
+}
+
+// stripSecrets removes instance_secret from a config file of any schema
+// version: from the top level of unversioned files and from each profile.
+func stripSecrets(b []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	delete(raw, "instance_secret")
+	if profiles, ok := raw["profiles"].(map[string]any); ok {
+		for _, p := range profiles {
+			if p, ok := p.(map[string]any); ok {
+				delete(p, "instance_secret")
+			}
+		}
+	}
+	return json.MarshalIndent(raw, "", "  ")
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// memStore is a SecretStore kept in memory.
+type memStore map[string]string
+
+func (m memStore) Kind() string { return "memory" }
+
+func (m memStore) Get(profile string) (string, error) {
+	s, ok := m[profile]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return s, nil
+}
+
+func (m memStore) Set(profile, secret string) error {
+	m[profile] = secret
+	return nil
+}
+
+func (m memStore) Delete(profile string) error {
+	delete(m, profile)
+	return nil
+}
+
+func (m memStore) Profiles() ([]string, error) {
+	return slices.Sorted(maps.Keys(m)), nil
+}
+
+// useDir points Load and Save at a fresh directory with the given secret
+// store and writes config.json there if data is not empty.
+func useDir(t *testing.T, store SecretStore, data string) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetDir(dir)
+	SetSecretStore(store)
+	t.Cleanup(func() {
+		SetDir("")
+		SetSecretStore(nil)
+	})
+	if data != "" {
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantVersion int
+		want        map[string]any
+	}{
+		{
+			name:        "unversioned single backend",
+			in:          `{"backend_url":"http://localhost:8080","instance_key":"k","instance_secret":"s"}`,
+			wantVersion: 0,
+			want: map[string]any{
+				"schema_version": 1.0,
+				"active_profile": DefaultProfile,
+				"profiles": map[string]any{
+					DefaultProfile: map[string]any{"backend_url": "http://localhost:8080", "instance_key": "k", "instance_secret": "s"},
+				},
+			},
+		},
+		{
+			name:        "unversioned with profiles",
+			in:          `{"active_profile":"work","profiles":{"work":{"backend_url":"https://w.example.com"}}}`,
+			wantVersion: 0,
+			want: map[string]any{
+				"schema_version": 1.0,
+				"active_profile": "work",
+				"profiles": map[string]any{
+					"work": map[string]any{"backend_url": "https://w.example.com"},
+				},
+			},
+		},
+		{
+			name:        "empty",
+			in:          `{}`,
+			wantVersion: 0,
+			want:        map[string]any{"schema_version": 1.0},
+		},
+		{
+			name:        "current",
+			in:          `{"schema_version":1,"active_profile":"a"}`,
+			wantVersion: 1,
+			want:        map[string]any{"schema_version": 1.0, "active_profile": "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, version, err := migrate([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("migrated to %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestMigrateRejects(t *testing.T) {
+	for _, in := range []string{
+		`{"schema_version":99}`,
+		`{"schema_version":-1}`,
+		`{"schema_version":1.5}`,
+		`{"schema_version":"1"}`,
+		`not json`,
+	} {
+		if _, _, err := migrate([]byte(in)); err == nil {
+			t.Errorf("migrate(%s) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestLoadMigratesAndBacksUp(t *testing.T) {
+	const v0 = `{"backend_url":"http://localhost:8080","instance_key":"k","instance_secret":"s"}`
+	dir := useDir(t, nil, v0)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	p := cfg.Profiles[DefaultProfile]
+	if cfg.ActiveProfile != DefaultProfile || p == nil || p.InstanceKey != "k" || p.InstanceSecret != "s" {
+		t.Fatalf("Load = %+v, want the old fields in the default profile", cfg)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, version, _ := migrate(saved); version != CurrentSchemaVersion {
+		t.Errorf("saved config is at version %d, want %d", version, CurrentSchemaVersion)
+	}
+
+	backup := filepath.Join(dir, "config.json.v0.bak")
+	b, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatalf("no backup: %v", err)
+	}
+	// Without a secret store the secret is in the config file anyway.
+	if string(b) != v0 {
+		t.Errorf("backup = %s, want the original file", b)
+	}
+	if fi, err := os.Stat(backup); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("backup mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+	}
+
+	// Loading again finds nothing to migrate and leaves the backup alone.
+	if err := os.WriteFile(backup, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err != nil {
+		t.Fatalf("second Load: %v", err)
+	}
+	if b, _ := os.ReadFile(backup); string(b) != "old" {
+		t.Error("second Load rewrote the backup")
+	}
+}
+
+func TestLoadKeepsSecretsOutOfBackups(t *testing.T) {
+	store := memStore{}
+	dir := useDir(t, store, `{"backend_url":"http://localhost:8080","instance_key":"k","instance_secret":"s3cr3t"}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Profiles[DefaultProfile].InstanceSecret; got != "s3cr3t" {
+		t.Errorf("loaded secret = %q, want s3cr3t", got)
+	}
+	if store[DefaultProfile] != "s3cr3t" {
+		t.Errorf("secret store has %q, want s3cr3t", store[DefaultProfile])
+	}
+	for _, name := range []string{"config.json", "config.json.v0.bak"} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Contains(string(b), "s3cr3t") {
+			t.Errorf("%s contains the secret: %s", name, b)
+		}
+	}
+}
+
+func TestLoadMovesSecretsWithoutBackup(t *testing.T) {
+	store := memStore{}
+	dir := useDir(t, store, `{"schema_version":1,"active_profile":"default","profiles":{"default":{"backend_url":"http://localhost:8080","instance_key":"k","instance_secret":"s3cr3t"}}}`)
+
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if store[DefaultProfile] != "s3cr3t" {
+		t.Errorf("secret store has %q, want s3cr3t", store[DefaultProfile])
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if strings.Contains(string(b), "s3cr3t") {
+		t.Errorf("config.json still contains the secret: %s", b)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.bak"))
+	if len(matches) > 0 {
+		t.Errorf("backups written when only moving secrets: %v", matches)
+	}
+}
+
+func TestLoadWithMissingSecret(t *testing.T) {
+	useDir(t, memStore{}, `{"schema_version":1,"active_profile":"default","secret_store":"memory","profiles":{"default":{"backend_url":"http://localhost:8080","instance_key":"k"}}}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	p := cfg.Profiles[DefaultProfile]
+	if !p.SecretMissing || p.HasCredentials() {
+		t.Errorf("SecretMissing = %v, HasCredentials = %v; want true, false", p.SecretMissing, p.HasCredentials())
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	useDir(t, nil, `{"schema_version":1,"active_profile":"gone","profiles":{"a":{"backend_url":"ftp://x","instance_key":"k"}}}`)
+
+	_, err := Load()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load error = %v, want a ValidationError", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Errorf("problems = %q, want the URL, the missing secret and the active profile", verr.Problems)
+	}
+}
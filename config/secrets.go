@@ -0,0 +1,251 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrSecretNotFound  = errors.New("secret not found")
+	ErrStoreLocked     = errors.New("secret file is locked; a passphrase is required")
+	ErrWrongPassphrase = errors.New("wrong passphrase for secret file")
+)
+
+// SecretStore keeps instance secrets out of the plaintext config file,
+// keyed by profile name.
+type SecretStore interface {
+	// Kind names the backend and is recorded in the config file.
+	Kind() string
+	Get(profile string) (string, error)
+	Set(profile, secret string) error
+	Delete(profile string) error
+	// Profiles lists the profiles that have a secret stored.
+	Profiles() ([]string, error)
+}
+
+// OpenSecretStore returns the OS keyring when one is reachable and otherwise
+// the passphrase-encrypted file, which must be unlocked before use.
+func OpenSecretStore() (SecretStore, error) {
+	if ks, err := NewKeyringStore(); err == nil {
+		return ks, nil
+	}
+	path, err := secretFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(path), nil
+}
+
+func secretFilePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "secrets.enc"), nil
+}
+
+const (
+	fileStoreKind       = "file"
+	fileStoreIterations = 600_000
+	fileStoreSaltSize   = 16
+)
+
+// encryptedFile is the on-disk layout of the secret file: an AES-256-GCM
+// sealed JSON map of profile→secret, keyed by PBKDF2-SHA256 of the passphrase.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore is the fallback SecretStore for systems without a keyring.
+type FileStore struct {
+	path string
+
+	mu         sync.Mutex
+	passphrase string
+	unlocked   bool
+	salt       []byte
+	iterations int
+	key        []byte
+	secrets    map[string]string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Kind() string { return fileStoreKind }
+
+// Exists reports whether the secret file has been created yet.
+func (s *FileStore) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+func (s *FileStore) Locked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.unlocked
+}
+
+// Unlock decrypts the secret file with passphrase. If the file does not exist
+// yet, passphrase becomes the one used to create it.
+func (s *FileStore) Unlock(passphrase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.passphrase = passphrase
+		s.secrets = make(map[string]string)
+		s.unlocked = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("secret file: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, f.Salt, f.Iterations, 32)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("secret file: %w", err)
+	}
+
+	s.passphrase = passphrase
+	s.salt = f.Salt
+	s.iterations = f.Iterations
+	s.key = key
+	s.secrets = secrets
+	s.unlocked = true
+	return nil
+}
+
+func (s *FileStore) Get(profile string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.unlocked {
+		return "", ErrStoreLocked
+	}
+	secret, ok := s.secrets[profile]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (s *FileStore) Set(profile, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.unlocked {
+		return ErrStoreLocked
+	}
+	if cur, ok := s.secrets[profile]; ok && cur == secret {
+		return nil
+	}
+	s.secrets[profile] = secret
+	return s.writeLocked()
+}
+
+func (s *FileStore) Delete(profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.unlocked {
+		return ErrStoreLocked
+	}
+	if _, ok := s.secrets[profile]; !ok {
+		return nil
+	}
+	delete(s.secrets, profile)
+	return s.writeLocked()
+}
+
+func (s *FileStore) Profiles() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.unlocked {
+		return nil, ErrStoreLocked
+	}
+	return slices.Sorted(maps.Keys(s.secrets)), nil
+}
+
+func (s *FileStore) writeLocked() error {
+	if s.key == nil {
+		s.salt = make([]byte, fileStoreSaltSize)
+		if _, err := rand.Read(s.salt); err != nil {
+			return err
+		}
+		s.iterations = fileStoreIterations
+		key, err := pbkdf2.Key(sha256.New, s.passphrase, s.salt, s.iterations, 32)
+		if err != nil {
+			return err
+		}
+		s.key = key
+	}
+
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(encryptedFile{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: s.iterations,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b, 0o600)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
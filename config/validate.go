@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every problem found in a config file.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that every profile has a usable backend URL and a complete
+// key/secret pair, unless its secret went missing from the secret store, and
+// that the active profile exists.
+func (c *InstanceConfig) Validate() error {
+	var problems []string
+
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := c.Profiles[name]
+		if name == "" {
+			problems = append(problems, "profile with empty name")
+		}
+		if p == nil {
+			problems = append(problems, fmt.Sprintf("profile %q is empty", name))
+			continue
+		}
+		if u, err := url.Parse(p.BackendURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Sprintf("profile %q: backend_url %q is not an http(s) URL", name, p.BackendURL))
+		}
+		switch {
+		case p.InstanceKey != "" && p.InstanceSecret == "" && !p.SecretMissing:
+			problems = append(problems, fmt.Sprintf("profile %q: instance_key is set but the instance secret is missing", name))
+		case p.InstanceKey == "" && p.InstanceSecret != "":
+			problems = append(problems, fmt.Sprintf("profile %q: instance secret is set but instance_key is missing", name))
+		}
+	}
+
+	if c.ActiveProfile != "" && len(c.Profiles) > 0 {
+		if _, ok := c.Profiles[c.ActiveProfile]; !ok {
+			problems = append(problems, fmt.Sprintf("active_profile %q does not exist", c.ActiveProfile))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
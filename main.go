@@ -1,65 +1,192 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 
 	"watcher-client/api"
+	"watcher-client/api/fake"
+	"watcher-client/cache"
+	"watcher-client/cli"
 	"watcher-client/config"
+	"watcher-client/store"
 	"watcher-client/ui"
 )
 
-const backendURL = "http://localhost:8080"
-
 func main() {
-	cfg, err := config.Load()
+	profileFlag := flag.String("profile", "", "backend profile to use (env WATCHER_PROFILE)")
+	backendFlag := flag.String("backend-url", "", "backend URL for the selected profile (env WATCHER_BACKEND_URL)")
+	demoFlag := flag.Bool("demo", false, "run against a built-in fake backend with sample data")
+	flag.Parse()
+
+	if *demoFlag {
+		runDemo()
+		return
+	}
+
+	if flag.NArg() > 0 {
+		if !cli.IsCommand(flag.Arg(0)) {
+			cli.Run(nil, cli.Env{Stderr: os.Stderr})
+			os.Exit(2)
+		}
+		os.Exit(cli.Run(flag.Args(), cli.Env{
+			Stdin:      os.Stdin,
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+			Profile:    *profileFlag,
+			BackendURL: *backendFlag,
+		}))
+	}
+
+	secrets, err := config.OpenSecretStore()
 	if err != nil {
-		log.Fatalf("config load: %v", err)
+		log.Fatalf("secret store: %v", err)
 	}
+	config.SetSecretStore(secrets)
 
-	cfg.BackendURL = backendURL
+	a := app.New()
 
-	if cfg.InstanceKey == "" || cfg.InstanceSecret == "" {
-		if err := autoRegisterInstance(cfg); err != nil {
-			log.Fatalf("auto-register failed: %v", err)
-		}
-		if err := config.Save(cfg); err != nil {
-			log.Printf("config save error after register: %v", err)
+	var assets *cache.Cache
+	if dir, err := cache.DefaultDir(); err == nil {
+		assets = openAssetCache(dir)
+	} else {
+		log.Printf("asset cache disabled: %v", err)
+	}
+	var local *store.Store
+	mirrorDir, err := store.DefaultDir()
+	if err == nil {
+		var outboxDir string
+		if outboxDir, err = store.DefaultOutboxDir(); err == nil {
+			local = openStore(mirrorDir, outboxDir)
 		}
 	}
+	if err != nil {
+		log.Printf("offline mode disabled: %v", err)
+	}
 
-	client := api.NewClient(cfg.BackendURL, cfg.InstanceKey, cfg.InstanceSecret)
+	var cfg *config.InstanceConfig
+	start := func() {
+		cfg, err = config.Load()
+		if err != nil {
+			log.Fatalf("config load: %v", err)
+		}
+		startUI(a, cfg, assets, local, *profileFlag, *backendFlag)
+	}
 
-	a := app.New()
-	mw := ui.NewMainWindow(a, client)
+	if fs, ok := secrets.(*config.FileStore); ok && fs.Locked() {
+		if pass := os.Getenv("WATCHER_PASSPHRASE"); pass != "" {
+			if err := fs.Unlock(pass); err != nil {
+				log.Fatalf("secret store: %v", err)
+			}
+			start()
+		} else {
+			ui.ShowPassphrasePrompt(a, !fs.Exists(), func(pass string) error {
+				if err := fs.Unlock(pass); err != nil {
+					return err
+				}
+				start()
+				return nil
+			})
+		}
+	} else {
+		start()
+	}
 
 	defer func() {
+		if cfg == nil {
+			return
+		}
 		if err := config.Save(cfg); err != nil {
 			log.Printf("config save error: %v", err)
 		}
 	}()
 
-	mw.Window.ShowAndRun()
+	a.Run()
 }
 
-func autoRegisterInstance(cfg *config.InstanceConfig) error {
-	hostname, err := os.Hostname()
-	if err != nil || hostname == "" {
-		hostname = "watcher-device"
+// startUI opens the main window for the selected profile, or the setup
+// wizard if that profile has not been connected to a backend yet.
+func startUI(a fyne.App, cfg *config.InstanceConfig, assets *cache.Cache, local *store.Store, profileFlag, backendFlag string) {
+	name := firstNonEmpty(profileFlag, os.Getenv("WATCHER_PROFILE"), cfg.ActiveProfile, config.DefaultProfile)
+	backendURL := firstNonEmpty(backendFlag, os.Getenv("WATCHER_BACKEND_URL"))
+
+	startMainWindow := func(name string) {
+		cfg.ActiveProfile = name
+		profile := cfg.Profile(name)
+		client := api.NewClient(profile.BackendURL, profile.InstanceKey, profile.InstanceSecret)
+		mw := ui.NewMainWindow(a, client, cfg, assets, local)
+		mw.Window.Show()
 	}
 
-	log.Printf("No instance credentials found, registering new instance as %q...", hostname)
+	profile, ok := cfg.Profiles[name]
+	if ok && profile.HasCredentials() {
+		if backendURL != "" {
+			profile.BackendURL = backendURL
+		}
+		startMainWindow(name)
+	} else {
+		ui.ShowSetupWizard(a, cfg, name, firstNonEmpty(backendURL, config.DefaultBackendURL), startMainWindow)
+	}
+}
 
-	key, secret, err := api.RegisterInstance(cfg.BackendURL, hostname)
+// runDemo starts the UI against an in-process fake backend seeded with
+// sample monitors and changes. Nothing is written to the real config.
+func runDemo() {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := fake.SeedDemo(srv)
+
+	dir, err := os.MkdirTemp("", "watcher-demo-")
 	if err != nil {
-		return err
+		log.Fatalf("demo: %v", err)
 	}
+	defer os.RemoveAll(dir)
+	config.SetDir(dir)
+	config.SetSecretStore(nil)
 
-	cfg.InstanceKey = key
-	cfg.InstanceSecret = secret
+	cfg := &config.InstanceConfig{ActiveProfile: "demo"}
+	*cfg.Profile("demo") = config.Profile{BackendURL: srv.URL, InstanceKey: key, InstanceSecret: secret}
+	log.Printf("demo backend running at %s", srv.URL)
+	go fake.RunDemoActivity(srv, key, 20*time.Second)
 
-	log.Printf("Registered instance. Key: %s", key)
-	return nil
+	a := app.New()
+	startUI(a, cfg, openAssetCache(filepath.Join(dir, "assets")), openStore(filepath.Join(dir, "mirror"), filepath.Join(dir, "outbox")), "demo", "")
+	a.Run()
+}
+
+// openAssetCache opens the asset cache in dir. If that fails the UI runs
+// without one and downloads assets every time.
+func openAssetCache(dir string) *cache.Cache {
+	c, err := cache.Open(dir, cache.DefaultMaxBytes)
+	if err != nil {
+		log.Printf("asset cache disabled: %v", err)
+		return nil
+	}
+	return c
+}
+
+// openStore opens the offline mirror and outbox, or returns nil so the UI
+// shows nothing and queues nothing while the backend is unreachable.
+func openStore(mirrorDir, outboxDir string) *store.Store {
+	s, err := store.Open(mirrorDir, outboxDir)
+	if err != nil {
+		log.Printf("offline mode disabled: %v", err)
+		return nil
+	}
+	return s
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,289 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"watcher-client/api"
+)
+
+// OpKind is the kind of change queued in an Outbox.
+type OpKind string
+
+const (
+	OpCreate OpKind = "create"
+	OpUpdate OpKind = "update"
+	OpDelete OpKind = "delete"
+)
+
+// Op is a monitor change made while the backend was unreachable.
+type Op struct {
+	// ID orders ops in the queue; it is local to the outbox.
+	ID   uint64 `json:"id"`
+	Kind OpKind `json:"kind"`
+
+	// MonitorID is the monitor an update or delete applies to.
+	MonitorID uint64 `json:"monitor_id,omitempty"`
+	// BaseUpdatedAt is the monitor's UpdatedAt when the change was made. If
+	// the backend's copy has a different one by the time the op is replayed,
+	// someone else changed it meanwhile and the op is held as a conflict.
+	BaseUpdatedAt time.Time `json:"base_updated_at,omitempty"`
+
+	Create *api.CreateMonitorReq `json:"create,omitempty"`
+	Update *api.UpdateMonitorReq `json:"update,omitempty"`
+	// IdempotencyKey makes a replayed create safe to send twice.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	QueuedAt time.Time `json:"queued_at"`
+	// Conflict is set when replay found the monitor changed or deleted on
+	// the backend. Such ops are skipped until forced or discarded.
+	Conflict bool `json:"conflict,omitempty"`
+	// Error is the last reason replaying the op failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Outbox is a durable, ordered queue of monitor changes waiting to be sent to
+// one instance. Queuing a change for a monitor that already has one pending
+// merges the two, so each monitor has at most one op. All methods are safe
+// for concurrent use.
+type Outbox struct {
+	path string
+
+	mu     sync.Mutex
+	ops    []Op
+	nextID uint64
+}
+
+type outboxFile struct {
+	NextID uint64 `json:"next_id"`
+	Ops    []Op   `json:"ops"`
+}
+
+// DefaultOutboxDir returns the outbox directory under the user config dir.
+// Unlike the mirror it must survive cache cleanups, as it holds unsent work.
+func DefaultOutboxDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watcher-client", "outbox"), nil
+}
+
+func openOutbox(path string) (*Outbox, error) {
+	o := &Outbox{path: path, nextID: 1}
+
+	b, err := os.ReadFile(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f outboxFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	o.ops = f.Ops
+	if f.NextID > o.nextID {
+		o.nextID = f.NextID
+	}
+	return o, nil
+}
+
+// Ops returns the queued ops in the order they will be replayed.
+func (o *Outbox) Ops() []Op {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.ops)
+}
+
+// Len returns the number of queued ops.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.ops)
+}
+
+// QueueCreate queues a new monitor and returns the op ID that stands in for
+// its monitor ID until it has been created. key is the idempotency key the
+// create is replayed with; pass the one of an attempt that may have reached
+// the backend already, so that it is not created twice.
+func (o *Outbox) QueueCreate(key string, req api.CreateMonitorReq) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	op := o.newOp(OpCreate)
+	op.Create = &req
+	op.IdempotencyKey = key
+	if err := o.commit(append(slices.Clone(o.ops), op), o.nextID+1); err != nil {
+		return 0, err
+	}
+	return op.ID, nil
+}
+
+// QueueUpdate queues changes to the monitor base, as last seen from the
+// backend. They are merged into an update already queued for it.
+func (o *Outbox) QueueUpdate(base api.Monitor, req api.UpdateMonitorReq) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ops := slices.Clone(o.ops)
+	if i := o.indexOf(base.ID); i >= 0 && ops[i].Kind == OpUpdate {
+		merged := mergeUpdates(*ops[i].Update, req)
+		ops[i].Update = &merged
+		ops[i].Error = ""
+		return o.commit(ops, o.nextID)
+	} else if i >= 0 {
+		return errors.New("store: monitor is already queued for deletion")
+	}
+	op := o.newOp(OpUpdate)
+	op.MonitorID = base.ID
+	op.BaseUpdatedAt = base.UpdatedAt
+	op.Update = &req
+	return o.commit(append(ops, op), o.nextID+1)
+}
+
+// QueueDelete queues deleting the monitor base, replacing any update queued
+// for it.
+func (o *Outbox) QueueDelete(base api.Monitor) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ops := slices.Clone(o.ops)
+	if i := o.indexOf(base.ID); i >= 0 {
+		ops = slices.Delete(ops, i, i+1)
+	}
+	op := o.newOp(OpDelete)
+	op.MonitorID = base.ID
+	op.BaseUpdatedAt = base.UpdatedAt
+	return o.commit(append(ops, op), o.nextID+1)
+}
+
+// Remove drops an op, after it was sent or because the user discarded it.
+func (o *Outbox) Remove(id uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.ops {
+		if o.ops[i].ID == id {
+			return o.commit(slices.Delete(slices.Clone(o.ops), i, i+1), o.nextID)
+		}
+	}
+	return nil
+}
+
+// MarkFailed records why replaying op id failed. A conflict holds the op
+// until Force or Remove is called.
+func (o *Outbox) MarkFailed(id uint64, conflict bool, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.ops {
+		if o.ops[i].ID == id {
+			ops := slices.Clone(o.ops)
+			ops[i].Conflict = conflict
+			ops[i].Error = reason
+			return o.commit(ops, o.nextID)
+		}
+	}
+	return nil
+}
+
+// Force clears a conflict so the op overwrites the backend's copy on the
+// next replay.
+func (o *Outbox) Force(id uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.ops {
+		if o.ops[i].ID == id {
+			ops := slices.Clone(o.ops)
+			ops[i].Conflict = false
+			ops[i].Error = ""
+			ops[i].BaseUpdatedAt = time.Time{}
+			return o.commit(ops, o.nextID)
+		}
+	}
+	return nil
+}
+
+// newOp returns an op with the next free ID. The ID is only taken once the
+// op is committed with o.nextID+1.
+func (o *Outbox) newOp(kind OpKind) Op {
+	return Op{ID: o.nextID, Kind: kind, QueuedAt: time.Now()}
+}
+
+func (o *Outbox) indexOf(monitorID uint64) int {
+	for i, op := range o.ops {
+		if op.Kind != OpCreate && op.MonitorID == monitorID {
+			return i
+		}
+	}
+	return -1
+}
+
+// commit writes ops and nextID to disk and only then makes them the
+// outbox's state, so a failed write leaves memory matching the file.
+// o.mu must be held.
+func (o *Outbox) commit(ops []Op, nextID uint64) error {
+	if err := o.save(ops, nextID); err != nil {
+		return err
+	}
+	o.ops, o.nextID = ops, nextID
+	return nil
+}
+
+func (o *Outbox) save(ops []Op, nextID uint64) error {
+	if err := os.MkdirAll(filepath.Dir(o.path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(outboxFile{NextID: nextID, Ops: ops})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// mergeUpdates returns a with every field set in b overriding it.
+func mergeUpdates(a, b api.UpdateMonitorReq) api.UpdateMonitorReq {
+	if b.Name != nil {
+		a.Name = b.Name
+	}
+	if b.URL != nil {
+		a.URL = b.URL
+	}
+	if b.CSSSelector != nil {
+		a.CSSSelector = b.CSSSelector
+	}
+	if b.FrequencySeconds != nil {
+		a.FrequencySeconds = b.FrequencySeconds
+	}
+	if b.NotifyEmail != nil {
+		a.NotifyEmail = b.NotifyEmail
+	}
+	if b.NotifyEmailAddr != nil {
+		a.NotifyEmailAddr = b.NotifyEmailAddr
+	}
+	if b.Active != nil {
+		a.Active = b.Active
+	}
+	if b.TagIDs != nil {
+		a.TagIDs = b.TagIDs
+	}
+	return a
+}
@@ -0,0 +1,257 @@
+// Package store keeps what the client needs to work while the backend is
+// unreachable: a mirror of the last known monitors and change history of
+// each instance, and an outbox of monitor changes waiting to be sent.
+//
+// Each instance (backend URL plus instance key) gets its own mirror directory
+// with monitors.json, tags.json and one changes-<monitor id>.json per monitor whose
+// history has been opened, and its own outbox file. Change assets are not
+// stored here; they live in the asset cache.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"watcher-client/api"
+)
+
+// ErrNoData is returned when nothing has been mirrored yet.
+var ErrNoData = errors.New("store: nothing mirrored yet")
+
+// Store holds the mirrors and outboxes of all instances.
+type Store struct {
+	dir       string
+	outboxDir string
+
+	mu       sync.Mutex
+	mirrors  map[string]*Mirror
+	outboxes map[string]*Outbox
+}
+
+// DefaultDir returns the mirror directory under the user cache dir.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watcher-client", "mirror"), nil
+}
+
+// Open opens or creates the store, with mirrors in dir and outboxes in
+// outboxDir.
+func Open(dir, outboxDir string) (*Store, error) {
+	for _, d := range []string{dir, outboxDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{
+		dir:       dir,
+		outboxDir: outboxDir,
+		mirrors:   make(map[string]*Mirror),
+		outboxes:  make(map[string]*Outbox),
+	}, nil
+}
+
+func instanceID(backendURL, instanceKey string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(backendURL, "/") + "\n" + instanceKey))
+	return hex.EncodeToString(sum[:12])
+}
+
+// Mirror returns the mirror of the instance identified by instanceKey on
+// backendURL. It is created on first write.
+func (s *Store) Mirror(backendURL, instanceKey string) *Mirror {
+	id := instanceID(backendURL, instanceKey)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mirrors[id]
+	if !ok {
+		m = &Mirror{dir: filepath.Join(s.dir, id)}
+		s.mirrors[id] = m
+	}
+	return m
+}
+
+// Outbox returns the outbox of the instance identified by instanceKey on
+// backendURL. It is created on first write.
+func (s *Store) Outbox(backendURL, instanceKey string) (*Outbox, error) {
+	id := instanceID(backendURL, instanceKey)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.outboxes[id]; ok {
+		return o, nil
+	}
+	o, err := openOutbox(filepath.Join(s.outboxDir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	s.outboxes[id] = o
+	return o, nil
+}
+
+// Mirror holds the mirrored data of one instance. All methods are safe for
+// concurrent use.
+type Mirror struct {
+	dir string
+	mu  sync.Mutex
+}
+
+type monitorsFile struct {
+	SyncedAt time.Time     `json:"synced_at"`
+	Monitors []api.Monitor `json:"monitors"`
+}
+
+type tagsFile struct {
+	SyncedAt time.Time `json:"synced_at"`
+	Tags     []api.Tag `json:"tags"`
+}
+
+type changesFile struct {
+	SyncedAt time.Time         `json:"synced_at"`
+	Total    int               `json:"total"`
+	Items    []api.ChangeEvent `json:"items"`
+}
+
+// SaveMonitors replaces the mirrored monitor list with ms, as just returned
+// by the backend, and drops the history of monitors that no longer exist.
+func (m *Mirror) SaveMonitors(ms []api.Monitor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.write("monitors.json", monitorsFile{SyncedAt: time.Now(), Monitors: ms}); err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(ms))
+	for _, mon := range ms {
+		keep[changesName(mon.ID)] = true
+	}
+	files, _ := filepath.Glob(filepath.Join(m.dir, "changes-*.json"))
+	for _, f := range files {
+		if !keep[filepath.Base(f)] {
+			os.Remove(f)
+		}
+	}
+	return nil
+}
+
+// Monitors returns the mirrored monitor list and when it was last synced.
+func (m *Mirror) Monitors() ([]api.Monitor, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var f monitorsFile
+	if err := m.read("monitors.json", &f); err != nil {
+		return nil, time.Time{}, err
+	}
+	return f.Monitors, f.SyncedAt, nil
+}
+
+// SaveTags replaces the mirrored tags with tags.
+func (m *Mirror) SaveTags(tags []api.Tag) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.write("tags.json", tagsFile{SyncedAt: time.Now(), Tags: tags})
+}
+
+// Tags returns the mirrored tags.
+func (m *Mirror) Tags() ([]api.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var f tagsFile
+	if err := m.read("tags.json", &f); err != nil {
+		return nil, err
+	}
+	return f.Tags, nil
+}
+
+// SaveChanges merges one page of a monitor's history into the mirror.
+// Changes already mirrored are replaced by ID; total is the backend's count,
+// or -1 if unknown.
+func (m *Mirror) SaveChanges(monitorID uint64, items []api.ChangeEvent, total int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var f changesFile
+	if err := m.read(changesName(monitorID), &f); err != nil {
+		// Nothing mirrored yet, or unreadable: start over from this page.
+		f = changesFile{}
+	}
+
+	byID := make(map[uint64]int, len(f.Items))
+	for i, c := range f.Items {
+		byID[c.ID] = i
+	}
+	for _, c := range items {
+		if i, ok := byID[c.ID]; ok {
+			f.Items[i] = c
+		} else {
+			byID[c.ID] = len(f.Items)
+			f.Items = append(f.Items, c)
+		}
+	}
+	sort.SliceStable(f.Items, func(i, j int) bool {
+		return f.Items[i].CreatedAt.After(f.Items[j].CreatedAt)
+	})
+	f.SyncedAt = time.Now()
+	f.Total = total
+	return m.write(changesName(monitorID), f)
+}
+
+// Changes returns the mirrored history of a monitor, newest first, and when
+// it was last synced.
+func (m *Mirror) Changes(monitorID uint64) ([]api.ChangeEvent, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var f changesFile
+	if err := m.read(changesName(monitorID), &f); err != nil {
+		return nil, time.Time{}, err
+	}
+	return f.Items, f.SyncedAt, nil
+}
+
+func changesName(monitorID uint64) string {
+	return fmt.Sprintf("changes-%d.json", monitorID)
+}
+
+func (m *Mirror) read(name string, v any) error {
+	b, err := os.ReadFile(filepath.Join(m.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoData
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (m *Mirror) write(name string, v any) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(m.dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(m.dir, name))
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/cache"
+)
+
+const (
+	assetTimeout    = 30 * time.Second
+	downloadTimeout = 45 * time.Second
+
+	// maxParallelAssets bounds how many assets one window downloads at once.
+	maxParallelAssets = 3
+)
+
+// assetLoader downloads the assets of one window concurrently, at most
+// maxParallelAssets at a time. Everything in flight or queued is abandoned
+// once ctx is cancelled. With a cache, copies already on disk are only
+// revalidated, and are used as they are if the backend cannot be reached.
+type assetLoader struct {
+	ctx   context.Context
+	cache *cache.Cache
+	slots chan struct{}
+}
+
+func newAssetLoader(ctx context.Context, assets *cache.Cache) *assetLoader {
+	return &assetLoader{ctx: ctx, cache: assets, slots: make(chan struct{}, maxParallelAssets)}
+}
+
+// fetch waits for a free slot, then downloads url, reporting to p if not nil.
+func (l *assetLoader) fetch(url string, timeout time.Duration, p *assetProgress) ([]byte, error) {
+	var (
+		cached     []byte
+		validators api.AssetValidators
+	)
+	if l.cache != nil {
+		if data, e, ok := l.cache.Get(url); ok {
+			cached = data
+			validators = api.AssetValidators{ETag: e.ETag, LastModified: e.LastModified}
+		}
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-l.ctx.Done():
+		return nil, l.ctx.Err()
+	}
+	defer func() { <-l.slots }()
+
+	ctx, cancel := context.WithTimeout(l.ctx, timeout)
+	defer cancel()
+	var report func(read, total int64)
+	if p != nil {
+		report = p.report
+	}
+	a, err := api.FetchAssetIfModified(ctx, url, validators, report)
+	var apiErr *api.APIError
+	switch {
+	case err != nil && cached != nil && l.ctx.Err() == nil && !errors.As(err, &apiErr):
+		p.setStatus("offline, using cached copy")
+		return cached, nil
+	case err != nil:
+		p.setStatus("failed")
+		return nil, err
+	case a.NotModified:
+		l.cache.Touch(url, a.ETag, a.LastModified)
+		p.setStatus("cached")
+		return cached, nil
+	}
+	if l.cache != nil {
+		if err := l.cache.Put(url, a.Data, a.ETag, a.LastModified); err != nil {
+			log.Printf("asset cache: %v", err)
+		}
+	}
+	return a.Data, nil
+}
+
+// fetchAll downloads the non-empty urls concurrently. Results and errors are
+// indexed like urls; empty urls are skipped.
+func (l *assetLoader) fetchAll(urls []string, timeout time.Duration, ps []*assetProgress) ([][]byte, []error) {
+	data := make([][]byte, len(urls))
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		if u == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data[i], errs[i] = l.fetch(u, timeout, ps[i])
+		}()
+	}
+	wg.Wait()
+	return data, errs
+}
+
+// assetProgress is one row in a loading tab showing how far the download of
+// an asset has got. report and setStatus may be called from any goroutine.
+type assetProgress struct {
+	name  string
+	root  *fyne.Container
+	label *widget.Label
+	bar   *widget.ProgressBar
+
+	lastReport time.Time
+}
+
+func newAssetProgress(name string) *assetProgress {
+	p := &assetProgress{
+		name:  name,
+		label: widget.NewLabel(""),
+		bar:   widget.NewProgressBar(),
+	}
+	p.root = container.NewVBox(p.label, p.bar)
+	return p
+}
+
+// reset shows the asset as waiting to start and returns its row.
+func (p *assetProgress) reset() fyne.CanvasObject {
+	p.lastReport = time.Time{}
+	p.label.SetText(p.name + ": queued")
+	p.bar.SetValue(0)
+	p.bar.Show()
+	return p.root
+}
+
+func (p *assetProgress) report(read, total int64) {
+	// Chunks arrive far faster than is worth redrawing.
+	now := time.Now()
+	if (total <= 0 || read < total) && now.Sub(p.lastReport) < 100*time.Millisecond {
+		return
+	}
+	p.lastReport = now
+
+	text := fmt.Sprintf("%s: %s", p.name, formatBytes(read))
+	value := 0.0
+	if total > 0 {
+		text += " of " + formatBytes(total)
+		value = float64(read) / float64(total)
+	}
+	fyne.Do(func() {
+		p.label.SetText(text)
+		if total > 0 {
+			p.bar.SetValue(value)
+		}
+	})
+}
+
+func (p *assetProgress) setStatus(status string) {
+	if p == nil {
+		return
+	}
+	fyne.Do(func() {
+		p.label.SetText(p.name + ": " + status)
+		p.bar.Hide()
+	})
+}
+
+// progressPlaceholder resets ps and stacks their rows, for use as the
+// loading state of asyncContent.
+func progressPlaceholder(ps ...*assetProgress) func() fyne.CanvasObject {
+	return func() fyne.CanvasObject {
+		rows := container.NewVBox()
+		for _, p := range ps {
+			rows.Add(p.reset())
+		}
+		return rows
+	}
+}
@@ -0,0 +1,456 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/store"
+)
+
+// bulkConcurrency is how many requests a bulk action has in flight at once.
+const bulkConcurrency = 6
+
+// bulkBar offers actions on the monitors checked in the table. It is only
+// shown while some are.
+type bulkBar struct {
+	mw    *MainWindow
+	root  *fyne.Container
+	label *widget.Label
+}
+
+func (mw *MainWindow) buildBulkBar() *bulkBar {
+	b := &bulkBar{mw: mw, label: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})}
+	deleteBtn := widget.NewButtonWithIcon("Delete…", theme.DeleteIcon(), mw.confirmBulkDelete)
+	deleteBtn.Importance = widget.DangerImportance
+	b.root = container.NewBorder(nil, nil, b.label, widget.NewButtonWithIcon("", theme.ContentClearIcon(), mw.clearChecked),
+		container.NewHBox(
+			widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), func() {
+				mw.runBulk(mw.Window, activeAction(false), mw.checkedMonitors())
+			}),
+			widget.NewButtonWithIcon("Resume", theme.MediaPlayIcon(), func() {
+				mw.runBulk(mw.Window, activeAction(true), mw.checkedMonitors())
+			}),
+			widget.NewButton("Frequency…", mw.showBulkFrequency),
+			widget.NewButton("Notifications…", mw.showBulkNotify),
+			deleteBtn,
+		))
+	b.root.Hide()
+	return b
+}
+
+func (b *bulkBar) update() {
+	n := len(b.mw.checked)
+	if n == 0 {
+		b.root.Hide()
+		return
+	}
+	b.label.SetText(plural(n, "monitor") + " checked")
+	b.root.Show()
+}
+
+func checkIcon(on bool) fyne.Resource {
+	if on {
+		return theme.CheckButtonCheckedIcon()
+	}
+	return theme.CheckButtonIcon()
+}
+
+// checkable reports whether r can be checked: monitors created offline
+// cannot until they have been sent.
+func checkable(r monitorRow) bool {
+	return r.group == nil && r.ID != 0
+}
+
+// checkTargets returns the rows the check cell of r stands for: r itself, or
+// the monitors of a tag header, including folded ones.
+func (mw *MainWindow) checkTargets(r monitorRow) []monitorRow {
+	if r.group == nil {
+		return []monitorRow{r}
+	}
+	var out []monitorRow
+	for _, m := range mw.filterRows(mw.allRows) {
+		if (r.group.tag == nil && len(m.tags) == 0) || (r.group.tag != nil && m.HasTag(r.group.tag.ID)) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// allChecked reports whether rows has checkable rows and all of them are
+// checked.
+func (mw *MainWindow) allChecked(rows []monitorRow) bool {
+	any := false
+	for _, r := range rows {
+		if !checkable(r) {
+			continue
+		}
+		if !mw.checked[r.ID] {
+			return false
+		}
+		any = true
+	}
+	return any
+}
+
+// toggleChecked checks rows, or unchecks them if all are checked already.
+func (mw *MainWindow) toggleChecked(rows []monitorRow) {
+	on := !mw.allChecked(rows)
+	for _, r := range rows {
+		switch {
+		case !checkable(r):
+		case on:
+			mw.checked[r.ID] = true
+		default:
+			delete(mw.checked, r.ID)
+		}
+	}
+	mw.table.Refresh()
+	mw.bulk.update()
+}
+
+func (mw *MainWindow) clearChecked() {
+	clear(mw.checked)
+	mw.table.Refresh()
+	mw.bulk.update()
+}
+
+func (mw *MainWindow) updateCheckCell(cell *monitorCell, r monitorRow) {
+	switch {
+	case r.group != nil:
+		cell.showIcon(checkIcon(mw.allChecked(mw.checkTargets(r))))
+	case r.ID == 0:
+		cell.showText("", false)
+	default:
+		cell.showIcon(checkIcon(mw.checked[r.ID]))
+	}
+}
+
+// checkedMonitors returns the checked monitors as last loaded, in table
+// order, including those hidden by the search or filters.
+func (mw *MainWindow) checkedMonitors() []api.Monitor {
+	var out []api.Monitor
+	for _, r := range mw.allRows {
+		if checkable(r) && mw.checked[r.ID] {
+			m, _ := mw.monitorByID(r.ID)
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (mw *MainWindow) showBulkFrequency() {
+	targets := mw.checkedMonitors()
+	if len(targets) == 0 {
+		return
+	}
+	freqEntry := widget.NewEntry()
+	if allSame(targets, func(m api.Monitor) int { return m.FrequencySeconds }) {
+		freqEntry.SetText(strconv.Itoa(targets[0].FrequencySeconds))
+	}
+	dialog.ShowForm("Change frequency of "+plural(len(targets), "monitor"), "Change", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Frequency (seconds)", freqEntry)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			freq, err := strconv.Atoi(strings.TrimSpace(freqEntry.Text))
+			if err != nil || freq <= 0 {
+				mw.showError("Please enter a valid positive frequency (seconds)")
+				return
+			}
+			mw.runBulk(mw.Window, bulkAction{verb: "Changing frequency of", done: "Changed", update: &api.UpdateMonitorReq{FrequencySeconds: &freq}}, targets)
+		}, mw.Window)
+}
+
+func (mw *MainWindow) showBulkNotify() {
+	targets := mw.checkedMonitors()
+	if len(targets) == 0 {
+		return
+	}
+	emailCheck := widget.NewCheck("Notify by email", nil)
+	emailCheck.SetChecked(true)
+	emailAddrEntry := widget.NewEntry()
+	emailAddrEntry.SetPlaceHolder("your@email.com")
+	if allSame(targets, func(m api.Monitor) string { return derefString(m.NotifyEmailAddr) }) {
+		emailAddrEntry.SetText(derefString(targets[0].NotifyEmailAddr))
+	}
+	form := dialog.NewForm("Change notifications of "+plural(len(targets), "monitor"), "Change", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("", emailCheck),
+			widget.NewFormItem("Notification email", emailAddrEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			notify := emailCheck.Checked
+			addr := strings.TrimSpace(emailAddrEntry.Text)
+			if notify && addr == "" {
+				mw.showError("Please enter an email address for notifications")
+				return
+			}
+			req := api.UpdateMonitorReq{NotifyEmail: &notify}
+			if addr != "" || !notify {
+				req.NotifyEmailAddr = &addr
+			}
+			mw.runBulk(mw.Window, bulkAction{verb: "Changing notifications of", done: "Changed", update: &req}, targets)
+		}, mw.Window)
+	form.Resize(fyne.NewSize(450, 220))
+	form.Show()
+}
+
+func (mw *MainWindow) confirmBulkDelete() {
+	targets := mw.checkedMonitors()
+	if len(targets) == 0 {
+		return
+	}
+	dialog.ShowConfirm("Delete monitors",
+		"Delete "+plural(len(targets), "monitor")+" and their change history? This cannot be undone.",
+		func(ok bool) {
+			if ok {
+				mw.runBulk(mw.Window, bulkAction{verb: "Deleting", done: "Deleted"}, targets)
+			}
+		}, mw.Window)
+}
+
+func allSame[T comparable](ms []api.Monitor, field func(api.Monitor) T) bool {
+	for _, m := range ms[1:] {
+		if field(m) != field(ms[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// bulkAction is a change made to many monitors at once: an update, or a
+// delete if update is nil.
+type bulkAction struct {
+	verb   string // e.g. "Pausing", followed by the monitor count
+	done   string // e.g. "Paused", for each monitor it worked for
+	update *api.UpdateMonitorReq
+}
+
+// activeAction pauses or resumes monitors.
+func activeAction(active bool) bulkAction {
+	if active {
+		return bulkAction{verb: "Resuming", done: "Resumed", update: &api.UpdateMonitorReq{Active: &active}}
+	}
+	return bulkAction{verb: "Pausing", done: "Paused", update: &api.UpdateMonitorReq{Active: &active}}
+}
+
+// bulkResult is what happened to one monitor of a bulk action.
+type bulkResult struct {
+	monitor api.Monitor
+	updated *api.Monitor
+	queued  bool
+	err     error
+}
+
+// runBulk applies action to targets, bulkConcurrency requests at a time,
+// with a progress dialog on parent that turns into a report of each monitor
+// when done. Monitors with changes already queued, or all of them while
+// offline, and those the backend turns out to be unreachable for, go to the
+// outbox.
+func (mw *MainWindow) runBulk(parent fyne.Window, action bulkAction, targets []api.Monitor) {
+	if len(targets) == 0 {
+		return
+	}
+	if action.update != nil {
+		// Monitors queued for deletion cannot be changed any more.
+		targets = slices.DeleteFunc(slices.Clone(targets), func(m api.Monitor) bool {
+			return slices.ContainsFunc(mw.allRows, func(r monitorRow) bool {
+				return r.ID == m.ID && r.op != nil && r.op.Kind == store.OpDelete
+			})
+		})
+	}
+	if len(targets) == 0 {
+		mw.status.note("Those monitors are queued for deletion and cannot be changed.")
+		return
+	}
+
+	results := make([]bulkResult, len(targets))
+	var send []int
+	for i, m := range targets {
+		results[i].monitor = m
+		if mw.offline.active || mw.hasQueued(m.ID) {
+			results[i].queued = true
+		} else {
+			send = append(send, i)
+		}
+	}
+
+	title := action.verb + " " + plural(len(targets), "monitor")
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(targets))
+	progress.SetValue(float64(len(targets) - len(send)))
+	body := container.NewStack(container.NewVBox(widget.NewLabel(title+"…"), progress))
+	d := dialog.NewCustomWithoutButtons(title, body, parent)
+	ctx, cancel := context.WithCancel(mw.ctx)
+	cancelBtn := widget.NewButton("Cancel", cancel)
+	d.SetButtons([]fyne.CanvasObject{cancelBtn})
+	d.Resize(fyne.NewSize(420, 160))
+	d.Show()
+
+	client := mw.Client
+	mw.background(title+"…", func(context.Context) error {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, bulkConcurrency)
+		for _, i := range send {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				r := &results[i]
+				if err := ctx.Err(); err != nil {
+					r.err = err
+					return
+				}
+				if action.update != nil {
+					r.updated, r.err = client.UpdateMonitorContext(ctx, r.monitor.ID, *action.update)
+				} else {
+					r.err = client.DeleteMonitorContext(ctx, r.monitor.ID)
+					if errors.Is(r.err, api.ErrNotFound) {
+						r.err = nil // deleted meanwhile
+					}
+				}
+				if api.IsUnreachable(r.err) && ctx.Err() == nil {
+					r.queued, r.err = true, nil
+				}
+				fyne.Do(func() { progress.SetValue(progress.Value + 1) })
+			}()
+		}
+		wg.Wait()
+		return nil
+	}, func(error) {
+		cancel()
+		if client != mw.Client {
+			d.Hide()
+			return
+		}
+		mw.applyBulk(action, results)
+		mw.showBulkReport(parent, d, body, action, results)
+	})
+}
+
+// applyBulk shows the outcome of a bulk action in the table and queues the
+// monitors marked for the outbox.
+func (mw *MainWindow) applyBulk(action bulkAction, results []bulkResult) {
+	var o *store.Outbox
+	queued := 0
+	for i := range results {
+		r := &results[i]
+		switch {
+		case r.queued:
+			if o == nil {
+				o = mw.outbox()
+			}
+			if o == nil {
+				r.queued, r.err = false, errors.New("the backend cannot be reached and there is nowhere to queue the change")
+				continue
+			}
+			var err error
+			if action.update != nil {
+				err = o.QueueUpdate(r.monitor, *action.update)
+			} else {
+				err = o.QueueDelete(r.monitor)
+			}
+			if err != nil {
+				r.queued, r.err = false, fmt.Errorf("could not be queued: %w", err)
+				continue
+			}
+			queued++
+		case r.err != nil:
+		case r.updated != nil:
+			if j := slices.IndexFunc(mw.monitors, func(m api.Monitor) bool { return m.ID == r.updated.ID }); j >= 0 {
+				mw.monitors[j] = *r.updated
+			}
+		default:
+			mw.monitors = slices.DeleteFunc(mw.monitors, func(m api.Monitor) bool { return m.ID == r.monitor.ID })
+		}
+	}
+	mw.rebuildRows()
+	mw.saveMirror()
+	if queued > 0 && !mw.offline.active {
+		// As in queueOffline: go offline, or send what can be sent now.
+		mw.loadMonitors()
+	}
+}
+
+// showBulkReport replaces the progress in d with the outcome for each
+// monitor, failures first, and offers to retry those.
+func (mw *MainWindow) showBulkReport(parent fyne.Window, d *dialog.CustomDialog, body *fyne.Container, action bulkAction, results []bulkResult) {
+	var ok, queued int
+	var failed []api.Monitor
+	lines := container.NewVBox()
+	add := func(icon fyne.Resource, r bulkResult, msg string) {
+		label := widget.NewLabel(r.monitor.Name + ": " + msg)
+		label.Wrapping = fyne.TextWrapWord
+		lines.Add(container.NewBorder(nil, nil, widget.NewIcon(icon), nil, label))
+	}
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.monitor)
+			msg := describeError(r.err)
+			if errors.Is(r.err, context.Canceled) {
+				msg = "cancelled"
+			}
+			add(theme.ErrorIcon(), r, msg)
+		}
+	}
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+		case r.queued:
+			queued++
+			add(theme.HistoryIcon(), r, "queued until the backend is reachable")
+		default:
+			ok++
+			add(theme.ConfirmIcon(), r, strings.ToLower(action.done))
+		}
+	}
+
+	summary := fmt.Sprintf("%s %d of %s.", action.done, ok, plural(len(results), "monitor"))
+	if queued > 0 {
+		summary += fmt.Sprintf(" %d queued.", queued)
+	}
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(" %d failed.", len(failed))
+		mw.status.fail(summary, nil)
+	} else {
+		mw.status.note(summary)
+	}
+
+	header := widget.NewLabelWithStyle(summary, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	body.Objects = []fyne.CanvasObject{container.NewBorder(header, nil, nil, nil, container.NewVScroll(lines))}
+	body.Refresh()
+	buttons := []fyne.CanvasObject{widget.NewButton("Close", d.Hide)}
+	if len(failed) > 0 {
+		retry := widget.NewButton("Retry failed", func() {
+			d.Hide()
+			var current []api.Monitor
+			for _, m := range failed {
+				if m, ok := mw.monitorByID(m.ID); ok {
+					current = append(current, m)
+				}
+			}
+			mw.runBulk(parent, action, current)
+		})
+		retry.Importance = widget.HighImportance
+		buttons = append(buttons, retry)
+	}
+	d.SetButtons(buttons)
+	d.Resize(fyne.NewSize(520, 380))
+}
@@ -1,12 +1,10 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -14,84 +12,48 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"watcher-client/api"
+	"watcher-client/cache"
 )
 
-func ShowChangeDetailWindow(a fyne.App, c api.ChangeEvent, m api.Monitor) {
+func ShowChangeDetailWindow(a fyne.App, assets *cache.Cache, c api.ChangeEvent, m api.Monitor) {
 	w := a.NewWindow("Change – " + m.Name)
 
+	// Closing the window abandons every download that is still queued or running.
+	ctx, cancel := context.WithCancel(context.Background())
+	w.SetOnClosed(cancel)
+	loader := newAssetLoader(ctx, assets)
+
 	detailSize := fyne.NewSize(900, 600)
 	contentSize := fyne.NewSize(detailSize.Width-40, detailSize.Height-80)
 
-	diffContentHolder := container.NewStack(widget.NewLabel("Loading diff…"))
-	diffScroll := container.NewScroll(diffContentHolder)
+	var diffContent fyne.CanvasObject
+	if c.HTTPStatusPrev != nil && c.HTTPStatusCurr != nil && *c.HTTPStatusPrev != *c.HTTPStatusCurr {
+		label := widget.NewLabel(fmt.Sprintf("HTTP status changed from %d to %d.", *c.HTTPStatusPrev, *c.HTTPStatusCurr))
+		label.Wrapping = fyne.TextWrapWord
+		diffContent = label
+	} else {
+		diffContent = buildHTMLDiffView(loader, c.HTMLDiff)
+	}
+	diffScroll := container.NewScroll(diffContent)
 	diffScroll.SetMinSize(contentSize)
 
-	downloadsContentHolder := container.NewStack(widget.NewLabel("Loading downloads…"))
-	downloadsScroll := container.NewScroll(downloadsContentHolder)
+	// Snapshots that fail to download are listed as unavailable.
+	prevProgress := newAssetProgress("Previous HTML")
+	currProgress := newAssetProgress("Current HTML")
+	downloadsContent := asyncContent(ctx, progressPlaceholder(prevProgress, currProgress), "", func() (func() fyne.CanvasObject, error) {
+		data, _ := loader.fetchAll(
+			[]string{derefString(c.HTMLPrev), derefString(c.HTMLCurr)},
+			assetTimeout,
+			[]*assetProgress{prevProgress, currProgress},
+		)
+		return func() fyne.CanvasObject {
+			return buildDownloadsTab(loader, w, string(data[0]), string(data[1]), c)
+		}, nil
+	})
+	downloadsScroll := container.NewScroll(downloadsContent)
 	downloadsScroll.SetMinSize(contentSize)
 
-	screenshotContent := buildScreenshotContent(c)
-
-	loadAndShowDiff := func(prevURL, currURL *string, diffOverride func(prevHTML, currHTML string) fyne.CanvasObject) {
-		prevHTML, errPrev := loadHTMLFromURL(prevURL)
-		currHTML, errCurr := loadHTMLFromURL(currURL)
-
-		updateDiffContentWith := func(build func() fyne.CanvasObject) {
-			obj := build()
-			diffContentHolder.Objects = []fyne.CanvasObject{obj}
-			diffContentHolder.Refresh()
-		}
-		updateDownloadsContentWith := func(build func() fyne.CanvasObject) {
-			obj := build()
-			downloadsContentHolder.Objects = []fyne.CanvasObject{obj}
-			downloadsContentHolder.Refresh()
-		}
-
-		if errPrev != nil || errCurr != nil {
-			msg := "Failed to load HTML diff."
-			if errPrev != nil {
-				msg += fmt.Sprintf("\nPrev: %v", errPrev)
-			}
-			if errCurr != nil {
-				msg += fmt.Sprintf("\nCurr: %v", errCurr)
-			}
-			updateDiffContentWith(func() fyne.CanvasObject {
-				label := widget.NewLabel(msg)
-				label.Wrapping = fyne.TextWrapWord
-				return label
-			})
-			updateDownloadsContentWith(func() fyne.CanvasObject {
-				return buildDownloadsTab(w, "", "", c)
-			})
-			return
-		}
-
-		var diffObj fyne.CanvasObject
-		if diffOverride != nil {
-			diffObj = diffOverride(prevHTML, currHTML)
-		} else {
-			diffObj = buildHTMLDiffView(c.HTMLDiff)
-		}
-		updateDiffContentWith(func() fyne.CanvasObject {
-			return diffObj
-		})
-		updateDownloadsContentWith(func() fyne.CanvasObject {
-			return buildDownloadsTab(w, prevHTML, currHTML, c)
-		})
-	}
-
-	var statusDiffOverride func(prevHTML, currHTML string) fyne.CanvasObject
-	if c.HTTPStatusPrev != nil && c.HTTPStatusCurr != nil && *c.HTTPStatusPrev != *c.HTTPStatusCurr {
-		prevStatus := *c.HTTPStatusPrev
-		currStatus := *c.HTTPStatusCurr
-		statusDiffOverride = func(_, _ string) fyne.CanvasObject {
-			label := widget.NewLabel(fmt.Sprintf("HTTP status changed from %d to %d.", prevStatus, currStatus))
-			label.Wrapping = fyne.TextWrapWord
-			return label
-		}
-	}
-
-	loadAndShowDiff(c.HTMLPrev, c.HTMLCurr, statusDiffOverride)
+	screenshotContent := buildScreenshotContent(loader, c)
 
 	tabs := container.NewAppTabs(
 		container.NewTabItem("Text diff", diffScroll),
@@ -105,41 +67,12 @@ func ShowChangeDetailWindow(a fyne.App, c api.ChangeEvent, m api.Monitor) {
 	w.Show()
 }
 
-func loadHTMLFromURL(urlPtr *string) (string, error) {
-	if urlPtr == nil || *urlPtr == "" {
-		return "", nil
-	}
-	url := *urlPtr
-	fmt.Printf("diff: downloading HTML from %s\n", url)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Printf("diff: failed to GET %s: %v\n", url, err)
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		fmt.Printf("diff: GET %s returned HTTP %d\n", url, resp.StatusCode)
-		return "", fmt.Errorf("http %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("diff: failed to read body from %s: %v\n", url, err)
-		return "", err
-	}
-	fmt.Printf("diff: download succeeded from %s (%d bytes)\n", url, len(body))
-	return string(body), nil
-}
-
-func buildDownloadsTab(w fyne.Window, prevHTML, currHTML string, c api.ChangeEvent) fyne.CanvasObject {
+func buildDownloadsTab(l *assetLoader, w fyne.Window, prevHTML, currHTML string, c api.ChangeEvent) fyne.CanvasObject {
 	rows := []fyne.CanvasObject{
 		buildDownloadRow(w, "Previous HTML", "previous.html", prevHTML),
 		buildDownloadRow(w, "Current HTML", "current.html", currHTML),
-		buildRemoteDownloadRow(w, "Current screenshot", "current.png", c.ScreenshotCurr),
-		buildRemoteDownloadRow(w, "Previous screenshot", "previous.png", c.ScreenshotPrev),
+		buildRemoteDownloadRow(l, w, "Current screenshot", "current.png", c.ScreenshotCurr),
+		buildRemoteDownloadRow(l, w, "Previous screenshot", "previous.png", c.ScreenshotPrev),
 	}
 	return container.NewVBox(rows...)
 }
@@ -161,7 +94,7 @@ func buildDownloadRow(w fyne.Window, label, defaultFile string, content string)
 	return container.NewBorder(nil, nil, nil, action, text)
 }
 
-func buildRemoteDownloadRow(w fyne.Window, label, defaultFile string, urlPtr *string) fyne.CanvasObject {
+func buildRemoteDownloadRow(l *assetLoader, w fyne.Window, label, defaultFile string, urlPtr *string) fyne.CanvasObject {
 	text := widget.NewLabel(label)
 	text.Wrapping = fyne.TextWrapWord
 
@@ -170,9 +103,16 @@ func buildRemoteDownloadRow(w fyne.Window, label, defaultFile string, urlPtr *st
 		action = widget.NewLabel("Unavailable")
 	} else {
 		urlCopy := *urlPtr
-		action = widget.NewButton("Download", func() {
-			downloadAndSaveRemoteFile(w, defaultFile, urlCopy)
+		var btn *widget.Button
+		btn = widget.NewButton("Download", func() {
+			btn.Disable()
+			btn.SetText("Downloading…")
+			downloadAndSaveRemoteFile(l, w, defaultFile, urlCopy, func() {
+				btn.SetText("Download")
+				btn.Enable()
+			})
 		})
+		action = btn
 	}
 
 	return container.NewBorder(nil, nil, nil, action, text)
@@ -187,32 +127,29 @@ func autoSaveToDownloads(win fyne.Window, defaultName string, content string) {
 	saveBytesToDownloads(win, defaultName, []byte(content))
 }
 
-func downloadAndSaveRemoteFile(win fyne.Window, defaultName string, url string) {
+// downloadAndSaveRemoteFile fetches url in the background and saves it, then
+// calls done on the UI goroutine.
+func downloadAndSaveRemoteFile(l *assetLoader, win fyne.Window, defaultName string, url string, done func()) {
 	if url == "" {
 		dialog.ShowInformation("Download asset", "No asset available for download.", win)
+		done()
 		return
 	}
 
-	client := &http.Client{Timeout: 45 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to download asset: %w", err), win)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		dialog.ShowError(fmt.Errorf("asset download returned HTTP %d", resp.StatusCode), win)
-		return
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to read asset response: %w", err), win)
-		return
-	}
-
-	saveBytesToDownloads(win, defaultName, data)
+	go func() {
+		data, err := l.fetch(url, downloadTimeout, nil)
+		if l.ctx.Err() != nil {
+			return
+		}
+		fyne.Do(func() {
+			done()
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to download asset: %s", describeError(err)), win)
+				return
+			}
+			saveBytesToDownloads(win, defaultName, data)
+		})
+	}()
 }
 
 func saveBytesToDownloads(win fyne.Window, defaultName string, data []byte) {
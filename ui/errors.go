@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+)
+
+// describeError turns an API failure into a message that tells the user
+// what kind of problem occurred rather than echoing the raw response.
+func describeError(err error) string {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	msg := apiErr.Message
+	switch {
+	case errors.Is(apiErr, api.ErrUnauthorized):
+		msg = "This instance's credentials were rejected by the backend (revoked or invalid)."
+	case errors.Is(apiErr, api.ErrValidation):
+		if msg == "" {
+			msg = "The backend rejected the request."
+		}
+		for _, f := range apiErr.Fields {
+			msg += fmt.Sprintf("\n• %s: %s", f.Field, f.Message)
+		}
+	case errors.Is(apiErr, api.ErrUnavailable):
+		msg = fmt.Sprintf("The backend is currently unavailable (HTTP %d). Please try again later.", apiErr.StatusCode)
+	case msg == "":
+		msg = apiErr.Error()
+	}
+	if apiErr.RequestID != "" {
+		msg += "\nRequest ID: " + apiErr.RequestID
+	}
+	return msg
+}
+
+// markFieldError flags entry with the backend's message for field until the
+// user changes the rejected value.
+func markFieldError(entry *widget.Entry, apiErr *api.APIError, field string) {
+	if apiErr == nil {
+		return
+	}
+	msg := apiErr.FieldError(field)
+	if msg == "" {
+		return
+	}
+	rejected := entry.Text
+	fieldErr := errors.New(msg)
+	entry.Validator = func(s string) error {
+		if s == rejected {
+			return fieldErr
+		}
+		return nil
+	}
+	entry.AlwaysShowValidationError = true
+	entry.SetValidationError(fieldErr)
+}
+
+// fieldErrors returns err as an APIError when it carries per-field messages.
+func fieldErrors(err error) *api.APIError {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && len(apiErr.Fields) > 0 {
+		return apiErr
+	}
+	return nil
+}
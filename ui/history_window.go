@@ -1,51 +1,217 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 
 	"watcher-client/api"
+	"watcher-client/cache"
+	"watcher-client/store"
 )
 
-func ShowHistoryWindow(a fyne.App, client *api.Client, m api.Monitor) {
+// historyPrefetchRows is how close to the end of the list the user has to
+// scroll before the next page is requested.
+const historyPrefetchRows = 10
+
+// ShowHistoryWindow lists the changes of m, newest first. Pages loaded from
+// the backend are copied to mirror, if not nil, which is shown instead when
+// the backend cannot be reached. Changes detected while the window is open
+// are added as live reports them; live may be nil.
+func ShowHistoryWindow(a fyne.App, client *api.Client, assets *cache.Cache, mirror *store.Mirror, live *liveUpdates, m api.Monitor) {
 	w := a.NewWindow("History – " + m.Name)
 
-	var changes []api.ChangeEvent
+	ctx, cancel := context.WithCancel(context.Background())
+	stopListening := func() {}
+	w.SetOnClosed(func() {
+		cancel()
+		stopListening()
+	})
+
+	var (
+		changes    []api.ChangeEvent
+		nextCursor string
+		hasMore    = true
+		loading    bool
+		total      = -1
+		// generation is bumped by reload so that a page still in flight
+		// from before is dropped instead of landing in the emptied list.
+		generation int
+	)
+
+	updateTitle := func() {
+		switch {
+		case total >= 0:
+			w.SetTitle(fmt.Sprintf("History – %s (%d changes)", m.Name, total))
+		case hasMore:
+			w.SetTitle(fmt.Sprintf("History – %s (%d+ changes)", m.Name, len(changes)))
+		default:
+			w.SetTitle(fmt.Sprintf("History – %s (%d changes)", m.Name, len(changes)))
+		}
+	}
+
+	status := newStatusBar()
+	var loadMore, reload func()
+
 	list := widget.NewList(
-		func() int { return len(changes) },
+		func() int {
+			if hasMore {
+				return len(changes) + 1
+			}
+			return len(changes)
+		},
 		func() fyne.CanvasObject {
 			return widget.NewLabel("change")
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-			c := changes[i]
 			lbl := o.(*widget.Label)
+			if i >= len(changes) {
+				lbl.SetText("Loading more…")
+				loadMore()
+				return
+			}
+			if i >= len(changes)-historyPrefetchRows {
+				loadMore()
+			}
+			c := changes[i]
 			lbl.SetText(fmt.Sprintf("%s", c.CreatedAt.Format("2006-01-02 15:04:05")))
 		},
 	)
 
 	list.OnSelected = func(id widget.ListItemID) {
 		if id < 0 || id >= widget.ListItemID(len(changes)) {
+			list.Unselect(id)
 			return
 		}
-		ShowChangeDetailWindow(a, changes[id], m)
+		ShowChangeDetailWindow(a, assets, changes[id], m)
 	}
 
-	refresh := func() {
-		evts, err := client.ListChanges(m.ID)
-		if err != nil {
-			dialog.ShowError(err, w)
+	loadMore = func() {
+		if loading || !hasMore {
 			return
 		}
-		changes = evts
+		loading = true
+		cursor, gen := nextCursor, generation
+		status.busy("Loading changes…")
+		go func() {
+			page, err := client.ListChangesPage(ctx, m.ID, api.ChangesQuery{Cursor: cursor})
+			if ctx.Err() != nil {
+				return
+			}
+			var (
+				mirrored []api.ChangeEvent
+				syncedAt time.Time
+			)
+			switch {
+			case err == nil && mirror != nil:
+				if err := mirror.SaveChanges(m.ID, page.Items, page.Total); err != nil {
+					log.Printf("offline mirror: %v", err)
+				}
+			case cursor == "" && mirror != nil && api.IsUnreachable(err):
+				mirrored, syncedAt, _ = mirror.Changes(m.ID)
+			}
+			fyne.Do(func() {
+				status.done()
+				if gen != generation {
+					return
+				}
+				loading = false
+				if mirrored != nil {
+					changes = mirrored
+					hasMore = false
+					total = len(mirrored)
+					updateTitle()
+					list.Refresh()
+					status.fail(fmt.Sprintf("The backend cannot be reached. Showing changes as of %s (%s).",
+						syncedAt.Local().Format("2006-01-02 15:04"), formatAge(syncedAt)), reload)
+					return
+				}
+				if err != nil {
+					// Stop the "Loading more…" row from re-requesting the
+					// page until the user retries.
+					hasMore = false
+					list.Refresh()
+					status.fail("Failed to load changes: "+describeError(err), func() {
+						hasMore = true
+						list.Refresh()
+						loadMore()
+					})
+					return
+				}
+				// Skip changes already added live; they shift the pages.
+				for _, c := range page.Items {
+					if !containsChange(changes, c.ID) {
+						changes = append(changes, c)
+					}
+				}
+				nextCursor = page.NextCursor
+				hasMore = page.NextCursor != ""
+				total = page.Total
+				updateTitle()
+				list.Refresh()
+			})
+		}()
+	}
+
+	reload = func() {
+		generation++
+		loading = false
+		changes = nil
+		nextCursor = ""
+		hasMore = true
+		total = -1
+		list.UnselectAll()
 		list.Refresh()
+		loadMore()
+	}
+
+	if live != nil {
+		stopListening = live.listen(func(ev api.Event) {
+			switch {
+			case ev.Type == api.EventResync:
+				reload()
+			case ev.Type == api.EventMonitorDeleted && ev.MonitorID == m.ID:
+				status.fail("This monitor has been deleted.", nil)
+			case ev.Type == api.EventChangeDetected && ev.MonitorID == m.ID:
+				if containsChange(changes, ev.Change.ID) {
+					return
+				}
+				changes = append([]api.ChangeEvent{*ev.Change}, changes...)
+				if total >= 0 {
+					total++
+				}
+				updateTitle()
+				list.UnselectAll()
+				list.Refresh()
+				if mirror != nil {
+					c, n := *ev.Change, total
+					go func() {
+						if err := mirror.SaveChanges(m.ID, []api.ChangeEvent{c}, n); err != nil {
+							log.Printf("offline mirror: %v", err)
+						}
+					}()
+				}
+			}
+		})
 	}
 
-	w.SetContent(list)
+	w.SetContent(container.NewBorder(nil, status.root, nil, nil, list))
 	w.Resize(fyne.NewSize(600, 400))
 	w.Show()
 
-	refresh()
+	loadMore()
+}
+
+func containsChange(changes []api.ChangeEvent, id uint64) bool {
+	for _, c := range changes {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
 }
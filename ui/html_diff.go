@@ -1,21 +1,17 @@
 package ui
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
-
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
 )
 
 const (
-	diffKindInserted = "inserted"
-	diffKindDeleted  = "deleted"
-	diffKindReplaced = "replaced"
+	diffKindInserted = api.DiffInserted
+	diffKindDeleted  = api.DiffDeleted
+	diffKindReplaced = api.DiffReplaced
 )
 
 type diffSegment struct {
@@ -67,11 +63,8 @@ func decodeServerDiff(diffJSON string) ([]diffSegment, error) {
 	if diffJSON == "" {
 		return nil, nil
 	}
-	var raw []struct {
-		Text string `json:"text"`
-		Kind string `json:"kind,omitempty"`
-	}
-	if err := json.Unmarshal([]byte(diffJSON), &raw); err != nil {
+	raw, err := api.DecodeDiff([]byte(diffJSON))
+	if err != nil {
 		return nil, err
 	}
 	segments := make([]diffSegment, 0, len(raw))
@@ -93,32 +86,25 @@ func decodeServerDiff(diffJSON string) ([]diffSegment, error) {
 	return segments, nil
 }
 
-func buildHTMLDiffView(diffURL *string) fyne.CanvasObject {
+func buildHTMLDiffView(l *assetLoader, diffURL *string) fyne.CanvasObject {
 	if diffURL == nil || *diffURL == "" {
 		return widget.NewLabel("No HTML diff available")
 	}
-	segments, err := fetchAndDecodeDiff(*diffURL)
-	if err != nil {
-		label := widget.NewLabel(fmt.Sprintf("Failed to load HTML diff: %v", err))
-		label.Wrapping = fyne.TextWrapWord
-		return label
-	}
-	return renderDiffRichText(segments)
+	url := *diffURL
+	p := newAssetProgress("Diff")
+	return asyncContent(l.ctx, progressPlaceholder(p), "Failed to load HTML diff: ", func() (func() fyne.CanvasObject, error) {
+		segments, err := fetchAndDecodeDiff(l, url, p)
+		if err != nil {
+			return nil, err
+		}
+		return func() fyne.CanvasObject {
+			return renderDiffRichText(segments)
+		}, nil
+	})
 }
 
-func fetchAndDecodeDiff(url string) ([]diffSegment, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("http %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+func fetchAndDecodeDiff(l *assetLoader, url string, p *assetProgress) ([]diffSegment, error) {
+	body, err := l.fetch(url, assetTimeout, p)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/config"
+)
+
+func (mw *MainWindow) buildInstanceMenu() *fyne.Menu {
+	return fyne.NewMenu("Instance",
+		fyne.NewMenuItem("Rotate secret…", mw.confirmRotateSecret),
+		fyne.NewMenuItem("Re-register instance…", mw.confirmReRegister),
+		fyne.NewMenuItem("Link existing credentials…", mw.showRelinkDialog),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("New profile…", mw.showNewProfileDialog),
+	)
+}
+
+// storeCredentials writes a new key/secret pair to the config file and then
+// switches the client to it. The client is switched even if saving fails,
+// since the backend may no longer accept the old pair.
+func (mw *MainWindow) storeCredentials(key, secret string) error {
+	p := mw.activeProfile()
+	p.InstanceKey = key
+	p.InstanceSecret = secret
+	p.SecretMissing = false
+	err := config.Save(mw.Config)
+	mw.Client.SetCredentials(key, secret)
+	// The event stream was opened, or rejected, with the old credentials.
+	mw.live.start()
+	return err
+}
+
+func (mw *MainWindow) confirmRotateSecret() {
+	dialog.ShowConfirm(
+		"Rotate secret",
+		"Request a new instance secret from the backend? The current secret stops working immediately.",
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			var key, secret string
+			mw.background("Rotating instance secret…", func(ctx context.Context) error {
+				var err error
+				key, secret, err = mw.Client.RotateSecret(ctx)
+				return err
+			}, func(err error) {
+				if err != nil {
+					mw.status.fail("Rotate failed: "+describeError(err), nil)
+					return
+				}
+				if err := mw.storeCredentials(key, secret); err != nil {
+					mw.showError("The secret was rotated but could not be saved: " + err.Error() +
+						"\nKeep this window open and fix the config directory, then rotate again.")
+					return
+				}
+				mw.status.note("Instance secret rotated.")
+			})
+		},
+		mw.Window,
+	)
+}
+
+func (mw *MainWindow) confirmReRegister() {
+	dialog.ShowConfirm(
+		"Re-register instance",
+		"Register this device as a new instance? Monitors owned by the old instance will not be visible.",
+		func(ok bool) {
+			if ok {
+				mw.reRegister()
+			}
+		},
+		mw.Window,
+	)
+}
+
+func (mw *MainWindow) reRegister() {
+	var key, secret string
+	baseURL := mw.Client.BaseURL
+	mw.background("Registering instance…", func(ctx context.Context) error {
+		var err error
+		key, secret, err = api.RegisterInstanceContext(ctx, baseURL, defaultInstanceName())
+		return err
+	}, func(err error) {
+		if err != nil {
+			mw.status.fail("Registration failed: "+describeError(err), mw.reRegister)
+			return
+		}
+		if err := mw.storeCredentials(key, secret); err != nil {
+			mw.showError("Registered, but saving the config failed: " + err.Error())
+		}
+		mw.loadMonitors()
+	})
+}
+
+func (mw *MainWindow) showRelinkDialog() {
+	keyEntry := widget.NewEntry()
+	keyEntry.SetPlaceHolder("Instance key")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("Instance secret")
+
+	form := dialog.NewForm(
+		"Link existing credentials",
+		"Link",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Key", keyEntry),
+			widget.NewFormItem("Secret", secretEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			key := strings.TrimSpace(keyEntry.Text)
+			secret := strings.TrimSpace(secretEntry.Text)
+			if key == "" || secret == "" {
+				mw.showError("Both key and secret are required")
+				return
+			}
+
+			probe := api.NewClient(mw.Client.BaseURL, key, secret)
+			mw.background("Checking credentials…", func(ctx context.Context) error {
+				_, err := probe.ListMonitorsContext(ctx)
+				return err
+			}, func(err error) {
+				if err != nil {
+					mw.status.fail("These credentials were not accepted: "+describeError(err), nil)
+					return
+				}
+				if err := mw.storeCredentials(key, secret); err != nil {
+					mw.showError("Linked, but saving the config failed: " + err.Error())
+				}
+				mw.loadMonitors()
+			})
+		},
+		mw.Window,
+	)
+	form.Resize(fyne.NewSize(450, 220))
+	form.Show()
+}
+
+// handleUnauthorized is installed as api.Client.OnUnauthorized. It offers to
+// re-register or re-link once, instead of letting every call fail with 401.
+func (mw *MainWindow) handleUnauthorized(_ *api.APIError) {
+	fyne.Do(func() {
+		if mw.authPromptOpen {
+			return
+		}
+		mw.authPromptOpen = true
+
+		reRegister := widget.NewButton("Register as new instance", nil)
+		relink := widget.NewButton("Link existing credentials", nil)
+		msg := widget.NewLabel("The backend rejected this instance's credentials. They may have been revoked or rotated on another device.")
+		msg.Wrapping = fyne.TextWrapWord
+
+		d := dialog.NewCustom("Credentials rejected", "Later", container.NewVBox(msg, reRegister, relink), mw.Window)
+		d.SetOnClosed(func() { mw.authPromptOpen = false })
+		reRegister.OnTapped = func() {
+			d.Hide()
+			mw.reRegister()
+		}
+		relink.OnTapped = func() {
+			d.Hide()
+			mw.showRelinkDialog()
+		}
+		d.Resize(fyne.NewSize(420, 220))
+		d.Show()
+	})
+}
+
+func defaultInstanceName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "watcher-device"
+	}
+	return hostname
+}
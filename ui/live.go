@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"fyne.io/fyne/v2"
+
+	"watcher-client/api"
+)
+
+// liveUpdates follows the backend event stream of the current profile,
+// keeps the monitor list up to date with it and passes events on to open
+// history windows. All methods must be called on the UI goroutine.
+type liveUpdates struct {
+	mw     *MainWindow
+	cancel context.CancelFunc
+	// streaming is true while the stream is connected. Otherwise, e.g.
+	// while it reconnects or once the backend refused it for good, changes
+	// have to be polled for.
+	streaming bool
+
+	listeners map[int]func(api.Event)
+	nextID    int
+}
+
+func newLiveUpdates(mw *MainWindow) *liveUpdates {
+	return &liveUpdates{mw: mw, listeners: make(map[int]func(api.Event))}
+}
+
+// start (re)subscribes to the stream of the current client, e.g. after
+// switching profiles or changing credentials.
+func (l *liveUpdates) start() {
+	l.stop()
+	var ctx context.Context
+	ctx, l.cancel = context.WithCancel(l.mw.ctx)
+	client := l.mw.Client
+	go func() {
+		err := client.Subscribe(ctx, "", func(ev api.Event) {
+			fyne.Do(func() {
+				if ctx.Err() == nil {
+					l.apply(ev)
+				}
+			})
+		}, func(connected bool, _ error) {
+			fyne.Do(func() {
+				if ctx.Err() != nil {
+					return
+				}
+				l.streaming = connected
+				// The stream getting through means the backend is back,
+				// possibly before the offline probe notices.
+				if connected && l.mw.offline.active {
+					l.mw.loadMonitors()
+				}
+			})
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, api.ErrNotFound) {
+			log.Printf("live updates: backend has no event stream")
+		} else {
+			log.Printf("live updates stopped: %v", err)
+		}
+		fyne.Do(func() {
+			if ctx.Err() == nil {
+				l.streaming = false
+			}
+		})
+	}()
+}
+
+func (l *liveUpdates) stop() {
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+	l.streaming = false
+}
+
+// listen calls fn with every event until the returned func is called.
+func (l *liveUpdates) listen(fn func(api.Event)) (stop func()) {
+	id := l.nextID
+	l.nextID++
+	l.listeners[id] = fn
+	return func() { delete(l.listeners, id) }
+}
+
+func (l *liveUpdates) apply(ev api.Event) {
+	mw := l.mw
+	switch ev.Type {
+	case api.EventMonitorCreated, api.EventMonitorUpdated:
+		mw.upsertMonitor(*ev.Monitor)
+	case api.EventMonitorDeleted:
+		mw.removeMonitor(ev.MonitorID)
+	case api.EventRunCompleted:
+		for i := range mw.monitors {
+			if mw.monitors[i].ID == ev.MonitorID {
+				status := ev.Run.Status
+				mw.monitors[i].LastStatus = &status
+				if st := mw.statsOf(ev.MonitorID); ev.Run.CheckedAt.After(st.checkedAt) {
+					st.checkedAt = ev.Run.CheckedAt
+				}
+				mw.rebuildRows()
+				mw.saveMirror()
+				break
+			}
+		}
+	case api.EventChangeDetected:
+		// Changes not newer than the last one counted were reported already,
+		// e.g. by polling while the stream reconnected.
+		if st := mw.stats[ev.MonitorID]; st != nil && st.changesKnown {
+			if !ev.Change.CreatedAt.After(st.lastChange) {
+				return
+			}
+			st.lastChange = ev.Change.CreatedAt
+			st.changes++
+			mw.rebuildRows()
+		}
+	case api.EventTagCreated, api.EventTagUpdated:
+		mw.upsertTag(*ev.Tag)
+	case api.EventTagDeleted:
+		mw.removeTag(ev.Tag.ID)
+	case api.EventResync:
+		mw.loadMonitors()
+	}
+	l.dispatch(ev)
+}
+
+// dispatch passes ev on to the listeners without applying it to the monitors.
+func (l *liveUpdates) dispatch(ev api.Event) {
+	for _, fn := range l.listeners {
+		fn(ev)
+	}
+}
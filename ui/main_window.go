@@ -1,8 +1,10 @@
 package ui
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -12,128 +14,322 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"watcher-client/api"
+	"watcher-client/cache"
+	"watcher-client/config"
+	"watcher-client/store"
 )
 
 type MainWindow struct {
 	App    fyne.App
 	Window fyne.Window
 	Client *api.Client
-
+	Config *config.InstanceConfig
+	Assets *cache.Cache
+	Store  *store.Store
+
+	ctx     context.Context
+	status  *statusBar
+	offline *offlineState
+	live    *liveUpdates
+	notify  *notifier
+	tray    *tray
+
+	syncCancel context.CancelFunc
+	// stats holds what the monitor table shows about each monitor beyond
+	// what the backend reports with it.
+	stats map[uint64]*monitorStats
+
+	// monitors is what the backend (or the mirror) last reported; allRows
+	// is that with queued offline changes applied, and rows the part of it
+	// the table shows after searching and filtering. matched counts the
+	// monitors in rows, which may also hold tag headers.
 	monitors      []api.Monitor
-	list          *widget.List
-	selectedIndex int
+	allRows       []monitorRow
+	rows          []monitorRow
+	matched       int
+	tags          []api.Tag
+	collapsedTags map[uint64]bool
+	// checked holds the IDs of the monitors checked for bulk actions.
+	checked        map[uint64]bool
+	bulk           *bulkBar
+	tagsWindow     fyne.Window
+	tagsRefresh    func()
+	replaying      bool
+	table          *monitorTable
+	filters        *filterBar
+	profileSelect  *widget.Select
+	selectedIndex  int
+	authPromptOpen bool
+
+	addBtn, deleteBtn, historyBtn, editBtn *widget.Button
 }
 
-func NewMainWindow(a fyne.App, client *api.Client) *MainWindow {
-	w := a.NewWindow("Watcher – Desktop Client")
+// NewMainWindow builds the monitor list for client. assets and local may be
+// nil, in which case change assets are always downloaded and nothing can be
+// shown while the backend is unreachable.
+func NewMainWindow(a fyne.App, client *api.Client, cfg *config.InstanceConfig, assets *cache.Cache, local *store.Store) *MainWindow {
+	w := a.NewWindow(defaultWindowTitle)
 
 	mw := &MainWindow{
 		App:           a,
 		Window:        w,
 		Client:        client,
+		Config:        cfg,
+		Assets:        assets,
+		Store:         local,
+		status:        newStatusBar(),
 		selectedIndex: -1,
+		collapsedTags: make(map[uint64]bool),
+		checked:       make(map[uint64]bool),
 	}
-
-	var historyBtn *widget.Button
-	var detailsBtn *widget.Button
-	var deleteBtn *widget.Button
-
-	updateSelectionButtons := func() {}
-
-	mw.list = widget.NewList(
-		func() int { return len(mw.monitors) },
-		func() fyne.CanvasObject {
-			return widget.NewLabel("monitor")
-		},
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			m := mw.monitors[i]
-			label := o.(*widget.Label)
-			text := fmt.Sprintf("%s (%s)", m.Name, m.URL)
-			if !m.Active {
-				text += " [inactive]"
-			}
-			label.SetText(text)
-		},
-	)
-
-	mw.list.OnSelected = func(id widget.ListItemID) {
-		mw.selectedIndex = int(id)
-		updateSelectionButtons()
-	}
-
-	mw.list.OnUnselected = func(id widget.ListItemID) {
-		if mw.selectedIndex == int(id) {
+	var cancel context.CancelFunc
+	mw.ctx, cancel = context.WithCancel(context.Background())
+	w.SetOnClosed(cancel)
+	w.SetCloseIntercept(func() { mw.tray.closeWindow() })
+	client.OnUnauthorized = mw.handleUnauthorized
+	w.SetMainMenu(fyne.NewMainMenu(
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("Open latest change", func() { mw.tray.openLatest() }),
+			fyne.NewMenuItem("Pending changes…", mw.showPendingChanges),
+			fyne.NewMenuItem("Tags…", mw.showTags),
+			fyne.NewMenuItem("Settings…", func() { ShowSettingsWindow(a, mw.Assets, mw.Config, mw.startSync) }),
+		),
+		mw.buildInstanceMenu(),
+	))
+	mw.updateTitle()
+
+	mw.table = mw.buildMonitorTable()
+	mw.filters = mw.buildFilterBar()
+	mw.bulk = mw.buildBulkBar()
+	// The table cannot unselect by itself, so selectedIndex only changes
+	// here and when the rows are rebuilt.
+	mw.table.OnSelected = func(id widget.TableCellID) {
+		if id.Row >= len(mw.rows) {
+			return
+		}
+		switch r := mw.rows[id.Row]; {
+		case monitorColumns[id.Col].cmp == nil:
+			// The check column checks the row, or all monitors of a tag,
+			// and leaves the selection as it was.
+			mw.table.UnselectAll()
+			mw.toggleChecked(mw.checkTargets(r))
+		case r.group != nil:
+			// Tapping a tag header folds or unfolds its monitors.
 			mw.selectedIndex = -1
+			mw.toggleGroup(r.group)
+		default:
+			mw.selectedIndex = id.Row
+			mw.table.Refresh()
+			mw.updateActions()
 		}
-		updateSelectionButtons()
 	}
-	addBtn := widget.NewButton("Add monitor", func() {
-		mw.showAddMonitorDialog()
+	mw.addBtn = widget.NewButton("Add monitor", func() {
+		mw.showAddMonitorDialog(nil, nil)
 	})
-	deleteBtn = widget.NewButton("Delete", func() {
-		if mw.selectedIndex < 0 || mw.selectedIndex >= len(mw.monitors) {
+	mw.deleteBtn = widget.NewButton("Delete", func() {
+		r, ok := mw.selected()
+		if !ok {
 			mw.showInfo("No monitor selected")
 			return
 		}
-		m := mw.monitors[mw.selectedIndex]
-		mw.confirmDelete(m)
+		mw.confirmDelete(r)
 	})
 
-	historyBtn = widget.NewButton("History", func() {
-		if mw.selectedIndex < 0 || mw.selectedIndex >= len(mw.monitors) {
+	mw.historyBtn = widget.NewButton("History", func() {
+		r, ok := mw.selected()
+		if !ok {
 			mw.showInfo("No monitor selected")
 			return
 		}
-		m := mw.monitors[mw.selectedIndex]
-		ShowHistoryWindow(mw.App, mw.Client, m)
+		mw.showHistory(r.Monitor)
 	})
-	detailsBtn = widget.NewButton("Edit", func() {
-		if mw.selectedIndex < 0 || mw.selectedIndex >= len(mw.monitors) {
+	mw.editBtn = widget.NewButton("Edit", func() {
+		r, ok := mw.selected()
+		if !ok {
 			mw.showInfo("No monitor selected")
 			return
 		}
-		m := mw.monitors[mw.selectedIndex]
-		mw.showMonitorDetails(m, mw.selectedIndex)
+		mw.showMonitorDetails(r.Monitor)
 	})
 
-	historyBtn.Disable()
-	detailsBtn.Disable()
-	deleteBtn.Disable()
-
-	updateSelectionButtons = func() {
-		hasSelection := mw.selectedIndex >= 0 && mw.selectedIndex < len(mw.monitors)
-		if hasSelection {
-			historyBtn.Enable()
-			detailsBtn.Enable()
-			deleteBtn.Enable()
-		} else {
-			historyBtn.Disable()
-			detailsBtn.Disable()
-			deleteBtn.Disable()
-		}
-	}
-
-	topBar := container.NewHBox(addBtn, deleteBtn, historyBtn, detailsBtn)
-	content := container.NewBorder(topBar, nil, nil, nil, mw.list)
+	mw.offline = newOfflineState(mw)
+	mw.live = newLiveUpdates(mw)
+	mw.notify = newNotifier(mw)
+	mw.tray = newTray(mw)
+	mw.profileSelect = mw.buildProfileSelect()
+	topBar := container.NewBorder(nil, nil, container.NewHBox(mw.addBtn, mw.deleteBtn, mw.historyBtn, mw.editBtn), mw.profileSelect)
+	content := container.NewBorder(container.NewVBox(topBar, mw.filters.root, mw.bulk.root, mw.offline.banner), mw.status.root, nil, nil, mw.table)
 
 	w.SetContent(content)
 	w.Resize(fyne.NewSize(900, 600))
 
 	mw.loadMonitors()
-	updateSelectionButtons()
+	mw.live.start()
+	mw.startSync()
+	mw.updateActions()
 	return mw
 }
 
+func (mw *MainWindow) selected() (monitorRow, bool) {
+	if mw.selectedIndex < 0 || mw.selectedIndex >= len(mw.rows) || mw.rows[mw.selectedIndex].group != nil {
+		return monitorRow{}, false
+	}
+	return mw.rows[mw.selectedIndex], true
+}
+
+// updateActions enables the buttons that make sense for the current
+// selection. A monitor created offline can only be discarded until it has
+// been sent, and one queued for deletion cannot be changed any more.
+func (mw *MainWindow) updateActions() {
+	r, ok := mw.selected()
+	queuedDelete := ok && r.op != nil && r.op.Kind == store.OpDelete
+	setEnabled(mw.deleteBtn, ok && !queuedDelete)
+	setEnabled(mw.editBtn, ok && r.ID != 0 && !queuedDelete)
+	setEnabled(mw.historyBtn, ok && r.ID != 0)
+}
+
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
+	} else {
+		w.Disable()
+	}
+}
+
+// background runs work off the UI goroutine while the status bar shows msg,
+// then calls done with work's error back on the UI goroutine. Nothing is
+// called once the window has been closed.
+func (mw *MainWindow) background(msg string, work func(ctx context.Context) error, done func(err error)) {
+	mw.status.busy(msg)
+	ctx := mw.ctx
+	go func() {
+		err := work(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		fyne.Do(func() {
+			mw.status.done()
+			done(err)
+		})
+	}()
+}
+
 func (mw *MainWindow) loadMonitors() {
-	ms, err := mw.Client.ListMonitors()
-	if err != nil {
-		mw.showError("Failed to load monitors: " + err.Error())
-		return
+	var ms []api.Monitor
+	var tags []api.Tag
+	client := mw.Client
+	mirror := mw.mirror()
+	mw.background("Loading monitors…", func(ctx context.Context) error {
+		var err error
+		ms, err = client.ListMonitorsContext(ctx)
+		if err != nil {
+			return err
+		}
+		tags, err = client.ListTags(ctx)
+		if errors.Is(err, api.ErrNotFound) {
+			// The backend predates tags.
+			tags, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		if mirror != nil {
+			if err := mirror.SaveMonitors(ms); err != nil {
+				log.Printf("offline mirror: %v", err)
+			}
+			if err := mirror.SaveTags(tags); err != nil {
+				log.Printf("offline mirror: %v", err)
+			}
+		}
+		return nil
+	}, func(err error) {
+		if client != mw.Client {
+			// The profile was switched while loading.
+			return
+		}
+		if err != nil {
+			if api.IsUnreachable(err) && mw.offline.enter(mirror) {
+				return
+			}
+			mw.status.fail("Failed to load monitors: "+describeError(err), mw.loadMonitors)
+			return
+		}
+		mw.offline.leave()
+		// Keeps the selection, as this also runs on every background sync.
+		mw.monitors = ms
+		mw.setTags(tags)
+		mw.replayOutbox(ms)
+		mw.pollChanges(ms)
+	})
+}
+
+// replaceMonitor swaps in an updated copy of a monitor, matched by ID so a
+// reload that happened in the meantime does not put it in the wrong row.
+func (mw *MainWindow) replaceMonitor(m api.Monitor) {
+	for i := range mw.monitors {
+		if mw.monitors[i].ID == m.ID {
+			mw.monitors[i] = m
+			mw.rebuildRows()
+			mw.saveMirror()
+			return
+		}
+	}
+}
+
+// upsertMonitor applies a monitor the backend reported as created or
+// changed, adding it if it is not shown yet.
+func (mw *MainWindow) upsertMonitor(m api.Monitor) {
+	for i := range mw.monitors {
+		if mw.monitors[i].ID == m.ID {
+			mw.replaceMonitor(m)
+			return
+		}
+	}
+	mw.monitors = append([]api.Monitor{m}, mw.monitors...)
+	mw.rebuildRows()
+	mw.saveMirror()
+}
+
+// showHistory opens the history of m, which marks its changes as read.
+func (mw *MainWindow) showHistory(m api.Monitor) {
+	mw.tray.markRead(m.ID)
+	ShowHistoryWindow(mw.App, mw.Client, mw.Assets, mw.mirror(), mw.live, m)
+}
+
+// showChange opens a change of m and marks it as read.
+func (mw *MainWindow) showChange(c api.ChangeEvent, m api.Monitor) {
+	mw.tray.unread = slices.DeleteFunc(mw.tray.unread, func(u api.ChangeEvent) bool { return u.ID == c.ID })
+	mw.tray.update()
+	ShowChangeDetailWindow(mw.App, mw.Assets, c, m)
+}
+
+func (mw *MainWindow) monitorByID(id uint64) (api.Monitor, bool) {
+	for _, m := range mw.monitors {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return api.Monitor{}, false
+}
+
+func (mw *MainWindow) selectMonitor(id uint64) {
+	for i, r := range mw.rows {
+		if r.ID == id {
+			mw.table.Select(widget.TableCellID{Row: i, Col: 1})
+			return
+		}
+	}
+}
+
+func (mw *MainWindow) removeMonitor(id uint64) {
+	for i := range mw.monitors {
+		if mw.monitors[i].ID == id {
+			mw.monitors = append(mw.monitors[:i], mw.monitors[i+1:]...)
+			mw.rebuildRows()
+			mw.saveMirror()
+			return
+		}
 	}
-	mw.monitors = ms
-	mw.selectedIndex = -1
-	mw.list.UnselectAll()
-	mw.list.Refresh()
 }
 
 func (mw *MainWindow) showError(msg string) {
@@ -144,7 +340,9 @@ func (mw *MainWindow) showInfo(msg string) {
 	dialog.ShowInformation("Info", msg, mw.Window)
 }
 
-func (mw *MainWindow) showAddMonitorDialog() {
+// showAddMonitorDialog opens the create form. When prev is set the form is
+// refilled with a rejected request and apiErr's field errors are highlighted.
+func (mw *MainWindow) showAddMonitorDialog(prev *api.CreateMonitorReq, apiErr *api.APIError) {
 	nameEntry := widget.NewEntry()
 	urlEntry := widget.NewEntry()
 	cssEntry := widget.NewEntry()
@@ -157,18 +355,45 @@ func (mw *MainWindow) showAddMonitorDialog() {
 	emailAddrEntry := widget.NewEntry()
 	emailAddrEntry.SetPlaceHolder("your@email.com")
 
+	var prevTags []uint64
+	if prev != nil {
+		prevTags = prev.TagIDs
+	}
+	tagPicker, pickedTags := mw.tagPicker(prevTags)
+
+	if prev != nil {
+		nameEntry.SetText(prev.Name)
+		urlEntry.SetText(prev.URL)
+		if prev.CSSSelector != nil {
+			cssEntry.SetText(*prev.CSSSelector)
+		}
+		freqEntry.SetText(strconv.Itoa(prev.FrequencySeconds))
+		emailCheck.SetChecked(prev.NotifyEmail)
+		emailAddrEntry.SetText(prev.NotifyEmailAddr)
+
+		markFieldError(nameEntry, apiErr, "name")
+		markFieldError(urlEntry, apiErr, "url")
+		markFieldError(cssEntry, apiErr, "css_selector")
+		markFieldError(freqEntry, apiErr, "frequency_seconds")
+		markFieldError(emailAddrEntry, apiErr, "notify_email_address")
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("URL", urlEntry),
+		widget.NewFormItem("CSS selector", cssEntry),
+		widget.NewFormItem("Frequency (seconds)", freqEntry),
+		widget.NewFormItem("", emailCheck),
+		widget.NewFormItem("Notification email", emailAddrEntry),
+	}
+	if tagPicker != nil {
+		items = append(items, widget.NewFormItem("Tags", tagPicker))
+	}
 	form := dialog.NewForm(
 		"Add monitor",
 		"Create",
 		"Cancel",
-		[]*widget.FormItem{
-			widget.NewFormItem("Name", nameEntry),
-			widget.NewFormItem("URL", urlEntry),
-			widget.NewFormItem("CSS selector", cssEntry),
-			widget.NewFormItem("Frequency (seconds)", freqEntry),
-			widget.NewFormItem("", emailCheck),
-			widget.NewFormItem("Notification email", emailAddrEntry),
-		},
+		items,
 		func(confirmed bool) {
 			if !confirmed {
 				return
@@ -208,25 +433,68 @@ func (mw *MainWindow) showAddMonitorDialog() {
 				FrequencySeconds: freq,
 				NotifyEmail:      notifyEmail,
 				NotifyEmailAddr:  emailAddr,
+				TagIDs:           pickedTags(),
 			}
 
-			m, err := mw.Client.CreateMonitor(req)
-			if err != nil {
-				mw.showError("Create failed: " + err.Error())
+			// The same key goes with the request and the queued create, in
+			// case the request reached the backend before failing.
+			key := api.NewIdempotencyKey()
+			queue := func(o *store.Outbox) error {
+				_, err := o.QueueCreate(key, req)
+				return err
+			}
+			if mw.offline.active {
+				mw.queueOffline("New monitor", queue)
 				return
 			}
-			mw.monitors = append([]api.Monitor{*m}, mw.monitors...)
-			mw.selectedIndex = 0
-			mw.list.Refresh()
-			mw.list.Select(0)
+
+			var m *api.Monitor
+			mw.background("Creating monitor…", func(ctx context.Context) error {
+				var err error
+				m, err = mw.Client.CreateMonitorOnce(ctx, key, req)
+				return err
+			}, func(err error) {
+				if apiErr := fieldErrors(err); apiErr != nil {
+					mw.showAddMonitorDialog(&req, apiErr)
+					return
+				}
+				if api.IsUnreachable(err) {
+					mw.queueOffline("New monitor", queue)
+					return
+				}
+				if err != nil {
+					mw.status.fail("Create failed: "+describeError(err), nil)
+					return
+				}
+				// The event stream may have added it already.
+				mw.upsertMonitor(*m)
+				mw.selectMonitor(m.ID)
+			})
 		},
 		mw.Window,
 	)
-	form.Resize(fyne.NewSize(450, 380))
+	form.Resize(fyne.NewSize(450, 420))
 	form.Show()
 }
 
-func (mw *MainWindow) confirmDelete(m api.Monitor) {
+func (mw *MainWindow) confirmDelete(r monitorRow) {
+	if r.ID == 0 {
+		dialog.ShowConfirm("Discard monitor", "'"+r.Name+"' has not been sent to the backend yet. Discard it?", func(ok bool) {
+			if !ok {
+				return
+			}
+			if o := mw.outbox(); o != nil {
+				if err := o.Remove(r.op.ID); err != nil {
+					mw.status.fail("Discard failed: "+err.Error(), nil)
+				}
+			}
+			mw.rebuildRows()
+		}, mw.Window)
+		return
+	}
+
+	m := r.Monitor
+	queue := func(o *store.Outbox) error { return o.QueueDelete(m) }
 	dialog.ShowConfirm(
 		"Delete monitor",
 		"Delete monitor '"+m.Name+"'?",
@@ -234,57 +502,185 @@ func (mw *MainWindow) confirmDelete(m api.Monitor) {
 			if !ok {
 				return
 			}
-			if err := mw.Client.DeleteMonitor(m.ID); err != nil {
-				mw.showError("Delete failed: " + err.Error())
+			if mw.offline.active || mw.hasQueued(m.ID) {
+				mw.queueOffline("Delete", queue)
 				return
 			}
-			mw.loadMonitors()
+			mw.background("Deleting monitor…", func(ctx context.Context) error {
+				return mw.Client.DeleteMonitorContext(ctx, m.ID)
+			}, func(err error) {
+				if api.IsUnreachable(err) {
+					mw.queueOffline("Delete", queue)
+					return
+				}
+				if err != nil {
+					mw.status.fail("Delete failed: "+describeError(err), nil)
+					return
+				}
+				mw.removeMonitor(m.ID)
+			})
 		},
 		mw.Window,
 	)
 }
 
-func (mw *MainWindow) showMonitorDetails(m api.Monitor, index int) {
+func (mw *MainWindow) showMonitorDetails(m api.Monitor) {
+	mw.showMonitorDetailsWith(m, nil, nil)
+}
+
+// showMonitorDetailsWith opens the edit form, optionally refilled with a
+// rejected request whose field errors are highlighted.
+func (mw *MainWindow) showMonitorDetailsWith(m api.Monitor, prev *api.UpdateMonitorReq, apiErr *api.APIError) {
+	shown := m
+	if prev != nil {
+		shown = m.Apply(*prev)
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(shown.Name)
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(shown.URL)
+	cssEntry := widget.NewEntry()
+	cssEntry.SetText(derefString(shown.CSSSelector))
 	freqEntry := widget.NewEntry()
-	freqEntry.SetText(strconv.Itoa(m.FrequencySeconds))
+	freqEntry.SetText(strconv.Itoa(shown.FrequencySeconds))
+
+	emailCheck := widget.NewCheck("Notify by email", nil)
+	emailCheck.SetChecked(shown.NotifyEmail)
+	emailAddrEntry := widget.NewEntry()
+	emailAddrEntry.SetPlaceHolder("your@email.com")
+	emailAddrEntry.SetText(derefString(shown.NotifyEmailAddr))
+	desktopCheck := widget.NewCheck("Desktop notification on this device", nil)
+	desktopCheck.SetChecked(mw.activeProfile().Notifies(m.ID))
 
 	activeCheck := widget.NewCheck("Monitor is active", nil)
-	activeCheck.SetChecked(m.Active)
+	activeCheck.SetChecked(shown.Active)
+	tagPicker, pickedTags := mw.tagPicker(shown.TagIDs)
+
+	if prev != nil {
+		markFieldError(nameEntry, apiErr, "name")
+		markFieldError(urlEntry, apiErr, "url")
+		markFieldError(cssEntry, apiErr, "css_selector")
+		markFieldError(freqEntry, apiErr, "frequency_seconds")
+		markFieldError(emailAddrEntry, apiErr, "notify_email_address")
+	}
 
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("URL", urlEntry),
+		widget.NewFormItem("CSS selector", cssEntry),
+		widget.NewFormItem("Frequency (seconds)", freqEntry),
+		widget.NewFormItem("", emailCheck),
+		widget.NewFormItem("Notification email", emailAddrEntry),
+		widget.NewFormItem("", desktopCheck),
+		widget.NewFormItem("", activeCheck),
+	}
+	if tagPicker != nil {
+		items = append(items, widget.NewFormItem("Tags", tagPicker))
+	}
 	form := dialog.NewForm(
 		"Monitor details – "+m.Name,
 		"Save",
 		"Cancel",
-		[]*widget.FormItem{
-			widget.NewFormItem("Frequency (seconds)", freqEntry),
-			widget.NewFormItem("", activeCheck),
-		},
+		items,
 		func(confirmed bool) {
 			if !confirmed {
 				return
 			}
+			url := strings.TrimSpace(urlEntry.Text)
+			if url == "" {
+				mw.showError("URL is required")
+				return
+			}
+			name := strings.TrimSpace(nameEntry.Text)
+			if name == "" {
+				name = url
+			}
 			freq, err := strconv.Atoi(strings.TrimSpace(freqEntry.Text))
 			if err != nil || freq <= 0 {
 				mw.showError("Please enter a valid positive frequency (seconds)")
 				return
 			}
+			emailAddr := strings.TrimSpace(emailAddrEntry.Text)
+			if emailCheck.Checked && emailAddr == "" {
+				mw.showError("Please enter an email address for notifications")
+				return
+			}
 
-			req := api.UpdateMonitorReq{
-				FrequencySeconds: freq,
-				Active:           activeCheck.Checked,
+			// Only send what differs from the monitor as loaded.
+			var req api.UpdateMonitorReq
+			if name != m.Name {
+				req.Name = &name
+			}
+			if url != m.URL {
+				req.URL = &url
+			}
+			if css := cssEntry.Text; css != derefString(m.CSSSelector) {
+				req.CSSSelector = &css
+			}
+			if freq != m.FrequencySeconds {
+				req.FrequencySeconds = &freq
+			}
+			if notify := emailCheck.Checked; notify != m.NotifyEmail {
+				req.NotifyEmail = &notify
+			}
+			if emailAddr != derefString(m.NotifyEmailAddr) {
+				req.NotifyEmailAddr = &emailAddr
+			}
+			if active := activeCheck.Checked; active != m.Active {
+				req.Active = &active
+			}
+			if tags := pickedTags(); tagPicker != nil && !sameTagIDs(tags, m.TagIDs) {
+				req.TagIDs = &tags
+			}
+			// Desktop notifications are a setting of this device, not of
+			// the monitor, so they are saved locally right away.
+			if p := mw.activeProfile(); desktopCheck.Checked != p.Notifies(m.ID) {
+				p.SetNotifies(m.ID, desktopCheck.Checked)
+				if err := config.Save(mw.Config); err != nil {
+					mw.showError("Failed to save config: " + err.Error())
+				}
+			}
+			if req.IsEmpty() {
+				return
 			}
 
-			updated, err := mw.Client.UpdateMonitor(m.ID, req)
-			if err != nil {
-				mw.showError("Update failed: " + err.Error())
+			queue := func(o *store.Outbox) error { return o.QueueUpdate(m, req) }
+			if mw.offline.active || mw.hasQueued(m.ID) {
+				mw.queueOffline("Edit", queue)
 				return
 			}
 
-			mw.monitors[index] = *updated
-			mw.list.Refresh()
+			var updated *api.Monitor
+			mw.background("Saving monitor…", func(ctx context.Context) error {
+				var err error
+				updated, err = mw.Client.UpdateMonitorContext(ctx, m.ID, req)
+				return err
+			}, func(err error) {
+				if apiErr := fieldErrors(err); apiErr != nil {
+					mw.showMonitorDetailsWith(m, &req, apiErr)
+					return
+				}
+				if api.IsUnreachable(err) {
+					mw.queueOffline("Edit", queue)
+					return
+				}
+				if err != nil {
+					mw.status.fail("Update failed: "+describeError(err), nil)
+					return
+				}
+				mw.replaceMonitor(*updated)
+			})
 		},
 		mw.Window,
 	)
-	form.Resize(fyne.NewSize(350, 220))
+	form.Resize(fyne.NewSize(450, 500))
 	form.Show()
 }
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/config"
+)
+
+// querySaveDelay is how long after the last keystroke the search is saved.
+const querySaveDelay = time.Second
+
+// viewOption is a choice in one of the filter or sort selects.
+type viewOption struct {
+	value, label string
+}
+
+var statusFilters = []viewOption{
+	{"", "All statuses"},
+	{"active", "Active"},
+	{"inactive", "Inactive"},
+	{"error", "Failing"},
+}
+
+var emailFilters = []viewOption{
+	{"", "Any email setting"},
+	{"on", "Email on"},
+	{"off", "Email off"},
+}
+
+func sortOptions() []viewOption {
+	opts := []viewOption{{"", "Default order"}}
+	for _, c := range monitorColumns {
+		if c.cmp == nil {
+			continue
+		}
+		opts = append(opts, viewOption{c.key, "Sort by " + strings.ToLower(c.title)})
+	}
+	return append(opts, viewOption{"created", "Sort by creation"})
+}
+
+func optionLabels(opts []viewOption) []string {
+	var labels []string
+	for _, o := range opts {
+		labels = append(labels, o.label)
+	}
+	return labels
+}
+
+func optionLabel(opts []viewOption, value string) string {
+	for _, o := range opts {
+		if o.value == value {
+			return o.label
+		}
+	}
+	return opts[0].label
+}
+
+func optionValue(opts []viewOption, label string) string {
+	for _, o := range opts {
+		if o.label == label {
+			return o.value
+		}
+	}
+	return ""
+}
+
+// filterBar holds the search entry and the filter and sort selects above
+// the monitor table. Its widgets always show mw.Config.MonitorView.
+type filterBar struct {
+	root   fyne.CanvasObject
+	search *widget.Entry
+	status *widget.Select
+	email  *widget.Select
+	sort   *widget.Select
+	order  *widget.Button
+	group  *widget.Check
+
+	saveTimer *time.Timer
+}
+
+func (mw *MainWindow) buildFilterBar() *filterBar {
+	f := &filterBar{}
+	sorts := sortOptions()
+
+	f.search = widget.NewEntry()
+	f.search.SetPlaceHolder("Search name, URL, selector or tag")
+	f.search.ActionItem = widget.NewButtonWithIcon("", theme.ContentClearIcon(), func() { f.search.SetText("") })
+	f.status = widget.NewSelect(optionLabels(statusFilters), nil)
+	f.email = widget.NewSelect(optionLabels(emailFilters), nil)
+	f.sort = widget.NewSelect(optionLabels(sorts), nil)
+	f.order = widget.NewButtonWithIcon("", theme.MenuDropUpIcon(), func() {
+		view := mw.Config.MonitorView
+		view.SortDesc = !view.SortDesc
+		mw.setView(view)
+	})
+	f.group = widget.NewCheck("Group by tag", nil)
+	f.show(mw.Config.MonitorView)
+
+	f.search.OnChanged = func(q string) {
+		view := mw.Config.MonitorView
+		view.Query = q
+		mw.setView(view)
+	}
+	f.status.OnChanged = func(label string) {
+		view := mw.Config.MonitorView
+		view.Status = optionValue(statusFilters, label)
+		mw.setView(view)
+	}
+	f.email.OnChanged = func(label string) {
+		view := mw.Config.MonitorView
+		view.NotifyEmail = optionValue(emailFilters, label)
+		mw.setView(view)
+	}
+	f.sort.OnChanged = func(label string) {
+		view := mw.Config.MonitorView
+		if key := optionValue(sorts, label); key != view.Sort {
+			view.Sort, view.SortDesc = key, false
+			mw.setView(view)
+		}
+	}
+
+	f.group.OnChanged = func(on bool) {
+		view := mw.Config.MonitorView
+		view.GroupByTag = on
+		mw.setView(view)
+	}
+
+	f.root = container.NewBorder(nil, nil, nil, container.NewHBox(f.status, f.email, f.sort, f.order, f.group), f.search)
+	return f
+}
+
+func (f *filterBar) show(view config.MonitorView) {
+	if f.search.Text != view.Query {
+		f.search.SetText(view.Query)
+	}
+	// Select calls OnChanged even if the selection stays the same.
+	setSelected(f.status, optionLabel(statusFilters, view.Status))
+	setSelected(f.email, optionLabel(emailFilters, view.NotifyEmail))
+	setSelected(f.sort, optionLabel(sortOptions(), view.Sort))
+	if view.SortDesc {
+		f.order.SetIcon(theme.MenuDropDownIcon())
+	} else {
+		f.order.SetIcon(theme.MenuDropUpIcon())
+	}
+	f.group.SetChecked(view.GroupByTag)
+	if view.Sort == "" {
+		f.order.Disable()
+	} else {
+		f.order.Enable()
+	}
+}
+
+func setSelected(s *widget.Select, label string) {
+	if s.Selected != label {
+		s.SetSelected(label)
+	}
+}
+
+// setView shows the monitors as view says and saves it; changes to the
+// search only once typing pauses.
+func (mw *MainWindow) setView(view config.MonitorView) {
+	old := mw.Config.MonitorView
+	if view == old {
+		return
+	}
+	mw.Config.MonitorView = view
+	f := mw.filters
+	f.show(view)
+	mw.rebuildRows()
+
+	if f.saveTimer != nil {
+		f.saveTimer.Stop()
+		f.saveTimer = nil
+	}
+	old.Query = view.Query
+	if old == view {
+		f.saveTimer = time.AfterFunc(querySaveDelay, func() { fyne.Do(mw.saveView) })
+		return
+	}
+	mw.saveView()
+}
+
+func (mw *MainWindow) saveView() {
+	mw.filters.saveTimer = nil
+	if err := config.Save(mw.Config); err != nil {
+		log.Printf("save monitor view: %v", err)
+	}
+}
+
+// filterRows returns the rows matching the search and filters.
+func (mw *MainWindow) filterRows(rows []monitorRow) []monitorRow {
+	view := mw.Config.MonitorView
+	words := strings.Fields(strings.ToLower(view.Query))
+	var out []monitorRow
+	for _, r := range rows {
+		if matchesFilters(r, view) && matchesSearch(r, words) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func matchesFilters(r monitorRow, view config.MonitorView) bool {
+	switch view.Status {
+	case "active":
+		if !r.Active {
+			return false
+		}
+	case "inactive":
+		if r.Active {
+			return false
+		}
+	case "error":
+		if statusText(r) != "error" {
+			return false
+		}
+	}
+	switch view.NotifyEmail {
+	case "on":
+		return r.NotifyEmail
+	case "off":
+		return !r.NotifyEmail
+	}
+	return true
+}
+
+// matchesSearch reports whether every word occurs in the name, URL,
+// selector or a tag of r.
+func matchesSearch(r monitorRow, words []string) bool {
+	fields := []string{strings.ToLower(r.Name), strings.ToLower(r.URL)}
+	if r.CSSSelector != nil {
+		fields = append(fields, strings.ToLower(*r.CSSSelector))
+	}
+	for _, t := range r.tags {
+		fields = append(fields, strings.ToLower(t.Name))
+	}
+	for _, w := range words {
+		if !slices.ContainsFunc(fields, func(s string) bool { return strings.Contains(s, w) }) {
+			return false
+		}
+	}
+	return true
+}
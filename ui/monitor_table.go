@@ -0,0 +1,445 @@
+package ui
+
+import (
+	"cmp"
+	"image/color"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/config"
+)
+
+// monitorColumn is one column of the monitor table. key identifies it in the
+// saved column widths. The check column for bulk actions has neither text
+// nor cmp.
+type monitorColumn struct {
+	key   string
+	title string
+	width float32
+	text  func(r monitorRow) string
+	cmp   func(a, b monitorRow) int
+}
+
+var monitorColumns = []monitorColumn{
+	{key: "select", width: 36},
+	{key: "status", title: "Status", width: 90, text: statusText,
+		cmp: func(a, b monitorRow) int { return strings.Compare(statusText(a), statusText(b)) }},
+	{key: "name", title: "Name", width: 200, text: rowName,
+		cmp: func(a, b monitorRow) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) }},
+	{key: "url", title: "URL", width: 240,
+		text: func(r monitorRow) string { return r.URL },
+		cmp:  func(a, b monitorRow) int { return strings.Compare(a.URL, b.URL) }},
+	{key: "last_checked", title: "Last checked", width: 130,
+		text: func(r monitorRow) string { return formatOptionalAge(lastChecked(r)) },
+		cmp:  func(a, b monitorRow) int { return compareTimes(lastChecked(a), lastChecked(b)) }},
+	{key: "next_check", title: "Next check", width: 120, text: nextCheckText,
+		cmp: func(a, b monitorRow) int { return compareTimes(nextCheck(a), nextCheck(b)) }},
+	{key: "frequency", title: "Frequency", width: 110,
+		text: func(r monitorRow) string { return formatInterval(time.Duration(r.FrequencySeconds) * time.Second) },
+		cmp:  func(a, b monitorRow) int { return cmp.Compare(a.FrequencySeconds, b.FrequencySeconds) }},
+	{key: "last_change", title: "Last change", width: 130,
+		text: func(r monitorRow) string { return formatOptionalAge(r.stats.lastChangeAt()) },
+		cmp:  func(a, b monitorRow) int { return compareTimes(a.stats.lastChangeAt(), b.stats.lastChangeAt()) }},
+	{key: "changes", title: "Changes", width: 80,
+		text: func(r monitorRow) string {
+			if !r.stats.changesKnown {
+				return "—"
+			}
+			return strconv.Itoa(r.stats.changes)
+		},
+		cmp: func(a, b monitorRow) int { return cmp.Compare(a.stats.changeCount(), b.stats.changeCount()) }},
+	{key: "tags", title: "Tags", width: 160, text: tagNames,
+		cmp: func(a, b monitorRow) int {
+			return strings.Compare(strings.ToLower(tagNames(a)), strings.ToLower(tagNames(b)))
+		}},
+}
+
+func rowName(r monitorRow) string {
+	if p := r.pendingLabel(); p != "" {
+		return r.Name + " [" + p + "]"
+	}
+	return r.Name
+}
+
+func statusText(r monitorRow) string {
+	switch {
+	case !r.Active:
+		return "paused"
+	case r.LastStatus == nil || *r.LastStatus == "":
+		return "unknown"
+	default:
+		return *r.LastStatus
+	}
+}
+
+// statusColors returns the badge and text colors for a status.
+func statusColors(status string) (color.Color, color.Color) {
+	switch status {
+	case "ok":
+		return theme.Color(theme.ColorNameSuccess), theme.Color(theme.ColorNameForegroundOnSuccess)
+	case "error":
+		return theme.Color(theme.ColorNameError), theme.Color(theme.ColorNameForegroundOnError)
+	case "paused", "unknown":
+		return theme.Color(theme.ColorNameDisabledButton), theme.Color(theme.ColorNameForeground)
+	default:
+		return theme.Color(theme.ColorNameWarning), theme.Color(theme.ColorNameForegroundOnWarning)
+	}
+}
+
+// monitorStats is what the monitor table knows about a monitor besides what
+// the backend reports with it: when the event stream last reported it
+// checked, and its newest change and number of changes from its history.
+type monitorStats struct {
+	checkedAt time.Time
+	// changesKnown is set once the history has been looked up.
+	changesKnown bool
+	lastChange   time.Time
+	changes      int
+}
+
+func (s monitorStats) lastChangeAt() *time.Time {
+	if !s.changesKnown || s.changes == 0 {
+		return nil
+	}
+	return &s.lastChange
+}
+
+// changeCount returns the number of changes, or -1 if it is not known.
+func (s monitorStats) changeCount() int {
+	if !s.changesKnown {
+		return -1
+	}
+	return s.changes
+}
+
+// statsOf returns the stats of a monitor, creating them if needed.
+func (mw *MainWindow) statsOf(id uint64) *monitorStats {
+	if mw.stats == nil {
+		mw.stats = make(map[uint64]*monitorStats)
+	}
+	st, ok := mw.stats[id]
+	if !ok {
+		st = &monitorStats{}
+		mw.stats[id] = st
+	}
+	return st
+}
+
+// lastChecked returns when the monitor of r last ran, or nil if that is not
+// known. The backend updates a monitor whenever it records a run, so
+// UpdatedAt stands for it unless the stream reported a later run.
+func lastChecked(r monitorRow) *time.Time {
+	t := r.UpdatedAt
+	if r.stats.checkedAt.After(t) {
+		t = r.stats.checkedAt
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// nextCheck returns when the monitor of r is expected to run next, or nil
+// if it is paused or that is not known.
+func nextCheck(r monitorRow) *time.Time {
+	last := lastChecked(r)
+	if !r.Active || last == nil {
+		return nil
+	}
+	next := last.Add(time.Duration(r.FrequencySeconds) * time.Second)
+	return &next
+}
+
+func nextCheckText(r monitorRow) string {
+	if !r.Active {
+		return "paused"
+	}
+	if next := nextCheck(r); next != nil {
+		return formatUntil(*next)
+	}
+	return "—"
+}
+
+func formatOptionalAge(t *time.Time) string {
+	if t == nil {
+		return "—"
+	}
+	return formatAge(*t)
+}
+
+// compareTimes orders unknown times before known ones.
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+// sortCompare returns the comparison for a sort key: a column key, or
+// "created" for the creation time, which has no column. It returns nil for
+// unknown keys.
+func sortCompare(key string) func(a, b monitorRow) int {
+	if key == "created" {
+		return func(a, b monitorRow) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	}
+	for _, c := range monitorColumns {
+		if c.key == key {
+			return c.cmp
+		}
+	}
+	return nil
+}
+
+// sortRows orders rows as the user chose, keeping the backend's order
+// otherwise and between equal rows.
+func (mw *MainWindow) sortRows(rows []monitorRow) {
+	view := mw.Config.MonitorView
+	compare := sortCompare(view.Sort)
+	if compare == nil {
+		return
+	}
+	slices.SortStableFunc(rows, func(a, b monitorRow) int {
+		if view.SortDesc {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+}
+
+// sortByColumn sorts by column col, or reverses the order if it already is.
+// Tapping the check column header checks or unchecks all shown monitors
+// instead.
+func (mw *MainWindow) sortByColumn(col int) {
+	if monitorColumns[col].cmp == nil {
+		mw.toggleChecked(mw.rows)
+		return
+	}
+	view := mw.Config.MonitorView
+	if key := monitorColumns[col].key; view.Sort == key {
+		view.SortDesc = !view.SortDesc
+	} else {
+		view.Sort, view.SortDesc = key, false
+	}
+	mw.setView(view)
+}
+
+// monitorTable is the table of monitors. It remembers the column widths the
+// user drags to, which widget.Table has no way to report, and saves them in
+// the config.
+type monitorTable struct {
+	widget.Table
+	cfg *config.InstanceConfig
+
+	dragging bool
+	widths   map[string]float32
+}
+
+func (mw *MainWindow) buildMonitorTable() *monitorTable {
+	t := &monitorTable{cfg: mw.Config, widths: make(map[string]float32)}
+	t.Length = func() (int, int) { return len(mw.rows), len(monitorColumns) }
+	t.CreateCell = func() fyne.CanvasObject { return newMonitorCell() }
+	t.UpdateCell = func(id widget.TableCellID, o fyne.CanvasObject) {
+		cell := o.(*monitorCell)
+		if id.Row >= len(mw.rows) {
+			return
+		}
+		r := mw.rows[id.Row]
+		cell.setSelected(id.Row == mw.selectedIndex || (r.group == nil && r.ID != 0 && mw.checked[r.ID]))
+		switch col := monitorColumns[id.Col]; {
+		case col.key == "select":
+			mw.updateCheckCell(cell, r)
+		case r.group != nil:
+			mw.updateGroupCell(cell, r.group, col.key)
+		case col.key == "status":
+			status := statusText(r)
+			bg, fg := statusColors(status)
+			cell.showBadge(status, bg, fg)
+		default:
+			cell.showText(col.text(r), false)
+		}
+	}
+	t.ShowHeaderRow = true
+	t.CreateHeader = func() fyne.CanvasObject { return newColumnHeader(mw.sortByColumn, t.headerResized) }
+	t.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		h := o.(*columnHeader)
+		h.col = id.Col
+		h.label.SetText(monitorColumns[id.Col].title)
+		switch {
+		case monitorColumns[id.Col].cmp == nil:
+			h.icon.SetResource(checkIcon(mw.allChecked(mw.rows)))
+		case monitorColumns[id.Col].key != mw.Config.MonitorView.Sort:
+			h.icon.SetResource(nil)
+		case mw.Config.MonitorView.SortDesc:
+			h.icon.SetResource(theme.MenuDropDownIcon())
+		default:
+			h.icon.SetResource(theme.MenuDropUpIcon())
+		}
+	}
+	t.ExtendBaseWidget(t)
+
+	for i, c := range monitorColumns {
+		w := c.width
+		if saved, ok := mw.Config.ColumnWidths[c.key]; ok && saved > 0 {
+			w = saved
+		}
+		t.SetColumnWidth(i, w)
+	}
+	return t
+}
+
+// Dragged resizes a column when dragging a header separator.
+func (t *monitorTable) Dragged(e *fyne.DragEvent) {
+	t.dragging = true
+	t.Table.Dragged(e)
+}
+
+// DragEnd saves the column widths after a resize.
+func (t *monitorTable) DragEnd() {
+	t.Table.DragEnd()
+	if !t.dragging {
+		return
+	}
+	t.dragging = false
+	if len(t.widths) == 0 {
+		return
+	}
+	if t.cfg.ColumnWidths == nil {
+		t.cfg.ColumnWidths = make(map[string]float32)
+	}
+	for k, w := range t.widths {
+		t.cfg.ColumnWidths[k] = w
+	}
+	if err := config.Save(t.cfg); err != nil {
+		log.Printf("save column widths: %v", err)
+	}
+}
+
+// headerResized is told the width of each header the table lays out. Only
+// sizes seen during a drag are kept: headers are reused between columns when
+// scrolling, and may be resized before being told their new column.
+func (t *monitorTable) headerResized(col int, width float32) {
+	if t.dragging && col >= 0 && col < len(monitorColumns) {
+		t.widths[monitorColumns[col].key] = width
+	}
+}
+
+// monitorCell shows one cell of the monitor table: plain text, a colored
+// badge for statuses and tags, or a check mark.
+type monitorCell struct {
+	widget.BaseWidget
+	bg        *canvas.Rectangle
+	label     *widget.Label
+	icon      *widget.Icon
+	badgeBG   *canvas.Rectangle
+	badgeText *canvas.Text
+	badge     *fyne.Container
+}
+
+func newMonitorCell() *monitorCell {
+	c := &monitorCell{
+		bg:        canvas.NewRectangle(color.Transparent),
+		label:     widget.NewLabel(""),
+		icon:      widget.NewIcon(nil),
+		badgeBG:   canvas.NewRectangle(color.Transparent),
+		badgeText: canvas.NewText("", color.White),
+	}
+	c.label.Truncation = fyne.TextTruncateEllipsis
+	c.badgeBG.CornerRadius = theme.InputRadiusSize()
+	c.badgeText.TextStyle.Bold = true
+	c.badge = container.NewPadded(container.NewStack(c.badgeBG, container.NewCenter(c.badgeText)))
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *monitorCell) setSelected(selected bool) {
+	if selected {
+		c.bg.FillColor = theme.Color(theme.ColorNameSelection)
+	} else {
+		c.bg.FillColor = color.Transparent
+	}
+	c.bg.Refresh()
+}
+
+func (c *monitorCell) showText(text string, bold bool) {
+	c.badge.Hide()
+	c.icon.Hide()
+	c.label.TextStyle.Bold = bold
+	c.label.SetText(text)
+	c.label.Show()
+}
+
+func (c *monitorCell) showBadge(text string, bg, fg color.Color) {
+	c.label.Hide()
+	c.icon.Hide()
+	c.badgeBG.FillColor, c.badgeText.Color = bg, fg
+	c.badgeText.Text = text
+	c.badgeBG.Refresh()
+	c.badgeText.Refresh()
+	c.badge.Show()
+}
+
+func (c *monitorCell) showIcon(res fyne.Resource) {
+	c.label.Hide()
+	c.badge.Hide()
+	c.icon.SetResource(res)
+	c.icon.Show()
+}
+
+func (c *monitorCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(c.bg, c.label, c.icon, c.badge))
+}
+
+// columnHeader is a header of the monitor table that sorts by its column
+// when tapped. It is deliberately not hoverable, so the table still sees the
+// pointer over the separators it resizes columns with.
+type columnHeader struct {
+	widget.BaseWidget
+	col      int
+	label    *widget.Label
+	icon     *widget.Icon
+	onTap    func(col int)
+	onResize func(col int, width float32)
+}
+
+func newColumnHeader(onTap func(col int), onResize func(col int, width float32)) *columnHeader {
+	h := &columnHeader{
+		col:      -1,
+		label:    widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		icon:     widget.NewIcon(nil),
+		onTap:    onTap,
+		onResize: onResize,
+	}
+	h.label.Truncation = fyne.TextTruncateEllipsis
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *columnHeader) Tapped(*fyne.PointEvent) {
+	if h.col >= 0 {
+		h.onTap(h.col)
+	}
+}
+
+func (h *columnHeader) Resize(size fyne.Size) {
+	h.BaseWidget.Resize(size)
+	h.onResize(h.col, size.Width)
+}
+
+func (h *columnHeader) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, nil, h.icon, h.label))
+}
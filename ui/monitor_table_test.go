@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+// columnText returns what column key shows for the row of monitor id.
+func columnText(mw *MainWindow, key string, id uint64) string {
+	for _, col := range monitorColumns {
+		if col.key != key {
+			continue
+		}
+		for _, r := range mw.allRows {
+			if r.ID == id {
+				return col.text(r)
+			}
+		}
+	}
+	return ""
+}
+
+func TestStatusColumns(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	updated := time.Now().Add(-10 * time.Minute).UTC()
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 3600, Active: true, UpdatedAt: updated})
+	var last api.ChangeEvent
+	for range 3 {
+		last = srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+	}
+
+	mw := newTestWindow(t, srv, key, secret, true)
+	waitFor(t, "the history to be looked up", func() bool {
+		st := mw.stats[m.ID]
+		return st != nil && st.changesKnown
+	})
+	fyne.DoAndWait(func() {
+		for col, want := range map[string]string{
+			"last_checked": formatAge(updated),
+			"next_check":   formatUntil(updated.Add(time.Hour)),
+			"last_change":  formatAge(last.CreatedAt),
+			"changes":      "3",
+		} {
+			if got := columnText(mw, col, m.ID); got != want {
+				t.Errorf("%s = %q, want %q", col, got, want)
+			}
+		}
+	})
+
+	srv.CompleteRun(m.ID, "ok", true)
+	srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+	waitFor(t, "the streamed change", func() bool { return columnText(mw, "changes", m.ID) == "4" })
+	fyne.DoAndWait(func() {
+		if got, want := columnText(mw, "last_checked", m.ID), formatAge(time.Now()); got != want {
+			t.Errorf("last_checked after a run = %q, want %q", got, want)
+		}
+	})
+}
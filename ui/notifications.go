@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"watcher-client/api"
+)
+
+// notifyBatchDelay is how long new changes are collected before notifying,
+// so a burst of them becomes one notification.
+const notifyBatchDelay = 3 * time.Second
+
+// notifyOpenWindow is how long after a notification that could not report
+// clicks bringing the app to the front opens the changes it announced.
+const notifyOpenWindow = 30 * time.Second
+
+// clickableNotifications sends desktop notifications that report clicks.
+type clickableNotifications interface {
+	// send raises a notification and calls onClick on the UI goroutine if
+	// it is clicked.
+	send(title, content string, onClick func()) error
+}
+
+// notifier raises desktop notifications for changes the event stream
+// reports on monitors that have them turned on, and opens the changes when
+// one is clicked. All methods must be called on the UI goroutine.
+//
+// Fyne does not report clicks on notifications, so they are sent through the
+// platform's notification service where it can. Elsewhere, clicking a
+// notification brings the app to the front on most desktops, so the
+// announced changes are opened when that happens shortly after one.
+type notifier struct {
+	mw      *MainWindow
+	pending []api.ChangeEvent
+	timer   *time.Timer
+
+	clickableOnce sync.Once
+	clickable     clickableNotifications
+
+	unopened   []api.ChangeEvent
+	notifiedAt time.Time
+}
+
+func newNotifier(mw *MainWindow) *notifier {
+	n := &notifier{mw: mw}
+	mw.live.listen(n.handle)
+	mw.App.Lifecycle().SetOnEnteredForeground(n.openUnopened)
+	return n
+}
+
+func (n *notifier) handle(ev api.Event) {
+	if ev.Type != api.EventChangeDetected || !n.mw.activeProfile().Notifies(ev.MonitorID) {
+		return
+	}
+	n.pending = append(n.pending, *ev.Change)
+	if n.timer == nil {
+		n.timer = time.AfterFunc(notifyBatchDelay, func() { fyne.Do(n.flush) })
+	}
+}
+
+func (n *notifier) flush() {
+	n.timer = nil
+	if len(n.pending) == 0 {
+		return
+	}
+	changes := n.pending
+	n.pending = nil
+
+	var names []string
+	seen := make(map[uint64]bool)
+	for _, c := range changes {
+		if !seen[c.MonitorID] {
+			seen[c.MonitorID] = true
+			names = append(names, n.monitorName(c.MonitorID))
+		}
+	}
+
+	var title, content string
+	switch {
+	case len(changes) == 1:
+		title = "Change detected: " + names[0]
+		content = "Detected at " + changes[0].CreatedAt.Local().Format("15:04") + "."
+	case len(names) == 1:
+		title = fmt.Sprintf("%d changes detected: %s", len(changes), names[0])
+		content = "Latest at " + latestChange(changes).CreatedAt.Local().Format("15:04") + "."
+	default:
+		title = fmt.Sprintf("%d changes detected on %d monitors", len(changes), len(names))
+		if len(names) > 3 {
+			names = append(names[:3], fmt.Sprintf("%d more", len(names)-3))
+		}
+		content = strings.Join(names, ", ") + "."
+	}
+
+	n.clickableOnce.Do(func() {
+		var err error
+		if n.clickable, err = newClickableNotifications(); err != nil {
+			log.Printf("notifications: %v; opening changes when the app comes to the front instead", err)
+		}
+	})
+	if n.clickable != nil {
+		err := n.clickable.send(title, content, func() { n.open(changes) })
+		if err == nil {
+			return
+		}
+		log.Printf("notifications: %v", err)
+	}
+	n.mw.App.SendNotification(fyne.NewNotification(title, content))
+	n.unopened = changes
+	n.notifiedAt = time.Now()
+}
+
+// reset drops pending notifications, e.g. after switching profiles.
+func (n *notifier) reset() {
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	n.pending, n.unopened = nil, nil
+}
+
+// openUnopened opens the changes of the last notification that could not
+// report clicks, if the app came to the front shortly after it.
+func (n *notifier) openUnopened() {
+	changes := n.unopened
+	n.unopened = nil
+	if len(changes) > 0 && time.Since(n.notifiedAt) <= notifyOpenWindow {
+		n.open(changes)
+	}
+}
+
+// open shows the changes of a notification: the change itself if there was
+// one, otherwise the history of its monitor, or the main window when several
+// monitors changed.
+func (n *notifier) open(changes []api.ChangeEvent) {
+	mw := n.mw
+	c := latestChange(changes)
+	m, ok := mw.monitorByID(c.MonitorID)
+	switch {
+	case !ok:
+		return
+	case len(changes) == 1:
+		mw.showChange(c, m)
+	case allSameMonitor(changes):
+		mw.showHistory(m)
+	default:
+		mw.tray.showWindow()
+	}
+}
+
+func (n *notifier) monitorName(id uint64) string {
+	if m, ok := n.mw.monitorByID(id); ok {
+		return m.Name
+	}
+	return fmt.Sprintf("monitor %d", id)
+}
+
+func latestChange(changes []api.ChangeEvent) api.ChangeEvent {
+	latest := changes[0]
+	for _, c := range changes[1:] {
+		if c.CreatedAt.After(latest.CreatedAt) {
+			latest = c
+		}
+	}
+	return latest
+}
+
+func allSameMonitor(changes []api.ChangeEvent) bool {
+	for _, c := range changes[1:] {
+		if c.MonitorID != changes[0].MonitorID {
+			return false
+		}
+	}
+	return true
+}
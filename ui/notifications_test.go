@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+)
+
+// recordedNotifications keeps the click handlers of sent notifications.
+type recordedNotifications struct {
+	clicks []func()
+}
+
+func (r *recordedNotifications) send(title, content string, onClick func()) error {
+	r.clicks = append(r.clicks, onClick)
+	return nil
+}
+
+// notifyOf queues a change of m for the next notification and sends it.
+func notifyOf(n *notifier, srv *fake.Server, m api.Monitor) api.ChangeEvent {
+	c := srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+	n.pending = append(n.pending, c)
+	n.flush()
+	return c
+}
+
+func TestClickingNotificationOpensChange(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	mw := newTestWindow(t, srv, key, secret, true)
+	waitFor(t, "the monitors", func() bool { return len(mw.monitors) == 1 })
+
+	rec := &recordedNotifications{}
+	fyne.DoAndWait(func() {
+		mw.notify.clickableOnce.Do(func() { mw.notify.clickable = rec })
+		notifyOf(mw.notify, srv, m)
+		if len(rec.clicks) != 1 {
+			t.Fatalf("sent %d clickable notifications, want 1", len(rec.clicks))
+		}
+		windows := len(mw.App.Driver().AllWindows())
+		// Coming to the front does not open anything for a clickable one.
+		mw.notify.openUnopened()
+		if n := len(mw.App.Driver().AllWindows()); n != windows {
+			t.Errorf("coming to the front opened %d windows", n-windows)
+		}
+		rec.clicks[0]()
+		if n := len(mw.App.Driver().AllWindows()); n != windows+1 {
+			t.Errorf("clicking opened %d windows, want 1", n-windows)
+		}
+	})
+}
+
+func TestComingToFrontAfterNotificationOpensChange(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	mw := newTestWindow(t, srv, key, secret, true)
+	waitFor(t, "the monitors", func() bool { return len(mw.monitors) == 1 })
+
+	fyne.DoAndWait(func() {
+		n := mw.notify
+		n.clickableOnce.Do(func() {}) // no clickable notifications
+		windows := len(mw.App.Driver().AllWindows())
+
+		notifyOf(n, srv, m)
+		n.openUnopened()
+		if got := len(mw.App.Driver().AllWindows()); got != windows+1 {
+			t.Fatalf("coming to the front opened %d windows, want 1", got-windows)
+		}
+		n.openUnopened()
+		if got := len(mw.App.Driver().AllWindows()); got != windows+1 {
+			t.Errorf("coming to the front again opened %d more windows", got-windows-1)
+		}
+
+		notifyOf(n, srv, m)
+		n.notifiedAt = time.Now().Add(-notifyOpenWindow - time.Second)
+		n.openUnopened()
+		if got := len(mw.App.Driver().AllWindows()); got != windows+1 {
+			t.Errorf("coming to the front long after a notification opened %d windows", got-windows-1)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+//go:build linux || freebsd || openbsd || netbsd
+
+package ui
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	notificationsName  = "org.freedesktop.Notifications"
+	notificationsPath  = "/org/freedesktop/Notifications"
+	notificationsIface = "org.freedesktop.Notifications"
+)
+
+// freedesktopNotifications sends notifications through the freedesktop
+// notification service with a default action, so that clicking one is
+// reported, which fyne's SendNotification does not do.
+type freedesktopNotifications struct {
+	conn *dbus.Conn
+
+	mu      sync.Mutex
+	onClick map[uint32]func()
+}
+
+// newClickableNotifications connects to the notification service and fails
+// if none is running or it does not support actions.
+func newClickableNotifications() (clickableNotifications, error) {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var caps []string
+	if err := conn.Object(notificationsName, notificationsPath).Call(notificationsIface+".GetCapabilities", 0).Store(&caps); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !slices.Contains(caps, "actions") {
+		conn.Close()
+		return nil, errors.New("notifications: the notification server does not support actions")
+	}
+	for _, member := range []string{"ActionInvoked", "NotificationClosed"} {
+		if err := conn.AddMatchSignal(dbus.WithMatchInterface(notificationsIface), dbus.WithMatchMember(member)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	n := &freedesktopNotifications{conn: conn, onClick: make(map[uint32]func())}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	go n.watch(signals)
+	return n, nil
+}
+
+func (n *freedesktopNotifications) send(title, content string, onClick func()) error {
+	var id uint32
+	err := n.conn.Object(notificationsName, notificationsPath).Call(notificationsIface+".Notify", 0,
+		"Watcher", uint32(0), "", title, content,
+		[]string{"default", "Open"}, map[string]dbus.Variant{}, int32(-1)).Store(&id)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.onClick[id] = onClick
+	n.mu.Unlock()
+	return nil
+}
+
+// watch runs the click handlers of notifications as the service reports
+// them, on the UI goroutine, until the connection is closed.
+func (n *freedesktopNotifications) watch(signals <-chan *dbus.Signal) {
+	for sig := range signals {
+		if len(sig.Body) < 2 {
+			continue
+		}
+		id, ok := sig.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+		n.mu.Lock()
+		fn := n.onClick[id]
+		delete(n.onClick, id)
+		n.mu.Unlock()
+		if sig.Name == notificationsIface+".ActionInvoked" && fn != nil {
+			fyne.Do(fn)
+		}
+	}
+}
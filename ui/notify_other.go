@@ -0,0 +1,12 @@
+//go:build !(linux || freebsd || openbsd || netbsd)
+
+package ui
+
+import "errors"
+
+// newClickableNotifications is only implemented for the freedesktop
+// notification service; other platforms fall back to opening the changes
+// when the app comes to the front shortly after a notification.
+func newClickableNotifications() (clickableNotifications, error) {
+	return nil, errors.New("notifications: clicks are not reported on this platform")
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/store"
+)
+
+// offlineProbeInterval is how often the main window checks whether an
+// unreachable backend is back.
+const offlineProbeInterval = 30 * time.Second
+
+// mirror returns the offline mirror of the current profile, or nil if there
+// is no store.
+func (mw *MainWindow) mirror() *store.Mirror {
+	if mw.Store == nil {
+		return nil
+	}
+	key, _ := mw.Client.Credentials()
+	return mw.Store.Mirror(mw.Client.BaseURL, key)
+}
+
+// saveMirror writes the monitors on screen to the offline mirror after a
+// change made through this window.
+func (mw *MainWindow) saveMirror() {
+	m := mw.mirror()
+	if m == nil || mw.offline.active {
+		return
+	}
+	ms := slices.Clone(mw.monitors)
+	go func() {
+		if err := m.SaveMonitors(ms); err != nil {
+			log.Printf("offline mirror: %v", err)
+		}
+	}()
+}
+
+// saveTags writes the known tags to the offline mirror.
+func (mw *MainWindow) saveTags() {
+	m := mw.mirror()
+	if m == nil || mw.offline.active {
+		return
+	}
+	tags := slices.Clone(mw.tags)
+	go func() {
+		if err := m.SaveTags(tags); err != nil {
+			log.Printf("offline mirror: %v", err)
+		}
+	}()
+}
+
+// offlineState shows the mirrored monitors while the backend is unreachable,
+// and reloads from the backend once it answers again. Changes made meanwhile
+// go to the outbox.
+type offlineState struct {
+	mw       *MainWindow
+	active   bool
+	syncedAt time.Time
+	cancel   context.CancelFunc
+
+	banner *fyne.Container
+	label  *widget.Label
+}
+
+func newOfflineState(mw *MainWindow) *offlineState {
+	o := &offlineState{mw: mw, label: widget.NewLabel("")}
+	o.label.Wrapping = fyne.TextWrapWord
+	o.label.Importance = widget.WarningImportance
+	retry := widget.NewButtonWithIcon("Retry now", theme.ViewRefreshIcon(), mw.loadMonitors)
+	o.banner = container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), retry, o.label)
+	o.banner.Hide()
+	return o
+}
+
+// enter switches the window to the mirrored monitors of m. It reports false,
+// leaving the window as it is, if nothing has been mirrored.
+func (o *offlineState) enter(m *store.Mirror) bool {
+	if m == nil {
+		return false
+	}
+	ms, syncedAt, err := m.Monitors()
+	if err != nil {
+		return false
+	}
+	// Mirrors written before tags existed have none.
+	tags, _ := m.Tags()
+
+	o.mw.tags = tags
+	o.mw.setMonitors(ms)
+	o.syncedAt = syncedAt
+	o.updateLabel()
+	o.banner.Show()
+	if !o.active {
+		o.active = true
+		o.startProbing()
+	}
+	return true
+}
+
+// leave hides the banner after the backend answered again.
+func (o *offlineState) leave() {
+	if o.active {
+		o.reset()
+		o.mw.status.note("Back online.")
+	}
+}
+
+// reset stops showing offline data without announcing anything, e.g. when
+// switching to another profile.
+func (o *offlineState) reset() {
+	if !o.active {
+		return
+	}
+	o.active = false
+	o.cancel()
+	o.banner.Hide()
+}
+
+func (o *offlineState) updateLabel() {
+	o.label.SetText(fmt.Sprintf(
+		"The backend cannot be reached. Showing monitors as of %s (%s); changes are queued and sent once it is back.",
+		o.syncedAt.Local().Format("2006-01-02 15:04"), formatAge(o.syncedAt)))
+}
+
+// startProbing checks the backend every offlineProbeInterval and reloads the
+// monitors once it answers. The age in the banner is refreshed meanwhile.
+func (o *offlineState) startProbing() {
+	var ctx context.Context
+	ctx, o.cancel = context.WithCancel(o.mw.ctx)
+	baseURL := o.mw.Client.BaseURL
+	go func() {
+		t := time.NewTicker(offlineProbeInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			err := api.CheckBackend(ctx, baseURL)
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(func() {
+				if !o.active {
+					return
+				}
+				if err == nil {
+					o.mw.loadMonitors()
+					return
+				}
+				o.updateLabel()
+			})
+		}
+	}()
+}
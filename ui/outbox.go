@@ -0,0 +1,370 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/store"
+)
+
+// monitorRow is one line of the monitor list: a monitor as the backend last
+// reported it, with any change queued in the outbox applied. Monitors created
+// offline have ID 0 until the create has been sent. In the grouped view, rows
+// with group set head the monitors of a tag instead.
+type monitorRow struct {
+	api.Monitor
+	op    *store.Op
+	stats monitorStats
+	tags  []api.Tag
+	group *rowGroup
+}
+
+// pendingLabel describes the queued change of the row, or "" if it has none.
+func (r monitorRow) pendingLabel() string {
+	switch {
+	case r.op == nil:
+		return ""
+	case r.op.Conflict:
+		return "conflict"
+	case r.op.Error != "":
+		return "failed"
+	default:
+		return "pending " + string(r.op.Kind)
+	}
+}
+
+// outbox returns the queue of offline changes for the current profile, or nil
+// if there is no store or it cannot be read.
+func (mw *MainWindow) outbox() *store.Outbox {
+	if mw.Store == nil {
+		return nil
+	}
+	key, _ := mw.Client.Credentials()
+	o, err := mw.Store.Outbox(mw.Client.BaseURL, key)
+	if err != nil {
+		log.Printf("outbox: %v", err)
+		return nil
+	}
+	return o
+}
+
+// setMonitors shows ms, as loaded from the backend or the mirror.
+func (mw *MainWindow) setMonitors(ms []api.Monitor) {
+	mw.monitors = ms
+	mw.selectedIndex = -1
+	mw.table.UnselectAll()
+	mw.rebuildRows()
+}
+
+// rebuildRows applies the outbox to the monitors and refreshes the table,
+// keeping the selected row selected.
+func (mw *MainWindow) rebuildRows() {
+	var selected *monitorRow
+	if r, ok := mw.selected(); ok {
+		selected = &r
+	}
+
+	var ops []store.Op
+	if o := mw.outbox(); o != nil {
+		ops = o.Ops()
+	}
+	byMonitor := make(map[uint64]*store.Op)
+	var rows []monitorRow
+	for i := range ops {
+		op := &ops[i]
+		if op.Kind == store.OpCreate {
+			rows = append(rows, monitorRow{Monitor: monitorFromCreate(*op.Create), op: op})
+		} else {
+			byMonitor[op.MonitorID] = op
+		}
+	}
+	for _, m := range mw.monitors {
+		r := monitorRow{Monitor: m, op: byMonitor[m.ID]}
+		if r.op != nil && r.op.Kind == store.OpUpdate {
+			r.Monitor = m.Apply(*r.op.Update)
+		}
+		if st := mw.stats[m.ID]; st != nil {
+			r.stats = *st
+		}
+		rows = append(rows, r)
+	}
+	for i := range rows {
+		rows[i].tags = mw.tagsOf(rows[i].TagIDs)
+	}
+	for id := range mw.checked {
+		if _, ok := mw.monitorByID(id); !ok {
+			delete(mw.checked, id)
+		}
+	}
+	mw.allRows = rows
+	shown := mw.filterRows(rows)
+	mw.sortRows(shown)
+	mw.matched = len(shown)
+	if mw.Config.MonitorView.GroupByTag {
+		shown = mw.groupRows(shown)
+	}
+	mw.rows = shown
+
+	mw.selectedIndex = -1
+	mw.table.UnselectAll()
+	mw.table.Refresh()
+	if selected != nil {
+		for i, r := range mw.rows {
+			if sameRow(r, *selected) {
+				mw.table.Select(widget.TableCellID{Row: i, Col: 1})
+				break
+			}
+		}
+	}
+	mw.updateActions()
+	mw.updateTitle()
+	mw.bulk.update()
+}
+
+func sameRow(a, b monitorRow) bool {
+	if a.ID != 0 || b.ID != 0 {
+		return a.ID == b.ID
+	}
+	return a.op != nil && b.op != nil && a.op.ID == b.op.ID
+}
+
+func monitorFromCreate(req api.CreateMonitorReq) api.Monitor {
+	m := api.Monitor{
+		Name:             req.Name,
+		URL:              req.URL,
+		CSSSelector:      req.CSSSelector,
+		FrequencySeconds: req.FrequencySeconds,
+		NotifyEmail:      req.NotifyEmail,
+		Active:           true,
+		TagIDs:           req.TagIDs,
+	}
+	if req.NotifyEmailAddr != "" {
+		addr := req.NotifyEmailAddr
+		m.NotifyEmailAddr = &addr
+	}
+	return m
+}
+
+// queueOffline records a change the backend could not take right now and
+// shows it as pending. queue is handed the outbox of the current profile.
+func (mw *MainWindow) queueOffline(what string, queue func(o *store.Outbox) error) {
+	o := mw.outbox()
+	if o == nil {
+		mw.status.fail(what+" failed: the backend cannot be reached and there is nowhere to queue the change.", nil)
+		return
+	}
+	if err := queue(o); err != nil {
+		mw.status.fail(what+" could not be queued: "+err.Error(), nil)
+		return
+	}
+	mw.rebuildRows()
+	mw.status.note(what + " queued; it will be sent when the backend is reachable.")
+	if !mw.offline.active {
+		// Either the backend just went away, which the reload turns into
+		// offline mode, or the change waits behind others that can be sent now.
+		mw.loadMonitors()
+	}
+}
+
+// hasQueued reports whether changes to the monitor id must wait behind ones
+// already in the outbox.
+func (mw *MainWindow) hasQueued(id uint64) bool {
+	for _, r := range mw.allRows {
+		if r.ID == id && r.op != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// replayOutbox sends the queued changes in order once the backend is
+// reachable again, then reloads the monitors. current is the monitor list
+// just loaded from the backend, used to detect conflicts.
+func (mw *MainWindow) replayOutbox(current []api.Monitor) {
+	o := mw.outbox()
+	if o == nil || mw.replaying || countReady(o.Ops()) == 0 {
+		return
+	}
+	mw.replaying = true
+	client := mw.Client
+	var sent, held int
+	mw.background("Sending queued changes…", func(ctx context.Context) error {
+		var err error
+		sent, held, err = replayOps(ctx, client, o, current)
+		return err
+	}, func(err error) {
+		mw.replaying = false
+		if client != mw.Client {
+			return
+		}
+		if err != nil {
+			mw.rebuildRows()
+			mw.status.fail("Sending queued changes stopped: "+describeError(err), mw.loadMonitors)
+			return
+		}
+		msg := fmt.Sprintf("Sent %d queued change(s).", sent)
+		if held > 0 {
+			msg += fmt.Sprintf(" %d need attention, see File → Pending changes.", held)
+		}
+		mw.status.note(msg)
+		mw.loadMonitors()
+	})
+}
+
+// countReady counts the ops that replay would send.
+func countReady(ops []store.Op) int {
+	n := 0
+	for _, op := range ops {
+		if op.Error == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// replayOps sends the ready ops of o in order. An update or delete whose
+// monitor changed on the backend since it was queued is held as a conflict
+// rather than overwriting someone else's change. It stops at the first
+// error that means the backend is unreachable again.
+func replayOps(ctx context.Context, client *api.Client, o *store.Outbox, current []api.Monitor) (sent, held int, err error) {
+	byID := make(map[uint64]api.Monitor, len(current))
+	for _, m := range current {
+		byID[m.ID] = m
+	}
+
+	for _, op := range o.Ops() {
+		if op.Error != "" {
+			held++
+			continue
+		}
+
+		var opErr error
+		switch op.Kind {
+		case store.OpCreate:
+			_, opErr = client.CreateMonitorOnce(ctx, op.IdempotencyKey, *op.Create)
+		case store.OpUpdate, store.OpDelete:
+			srv, ok := byID[op.MonitorID]
+			if !ok {
+				if op.Kind == store.OpDelete {
+					break // already gone
+				}
+				o.MarkFailed(op.ID, true, "The monitor was deleted on the backend.")
+				held++
+				continue
+			}
+			if !op.BaseUpdatedAt.IsZero() && !srv.UpdatedAt.Equal(op.BaseUpdatedAt) {
+				o.MarkFailed(op.ID, true, "The monitor was changed on the backend at "+
+					srv.UpdatedAt.Local().Format("2006-01-02 15:04")+" after this change was queued.")
+				held++
+				continue
+			}
+			if op.Kind == store.OpUpdate {
+				_, opErr = client.UpdateMonitorContext(ctx, op.MonitorID, *op.Update)
+			} else {
+				opErr = client.DeleteMonitorContext(ctx, op.MonitorID)
+				if errors.Is(opErr, api.ErrNotFound) {
+					opErr = nil
+				}
+			}
+		}
+
+		if api.IsUnreachable(opErr) || ctx.Err() != nil {
+			return sent, held, opErr
+		}
+		if opErr != nil {
+			o.MarkFailed(op.ID, false, describeError(opErr))
+			held++
+			continue
+		}
+		if err := o.Remove(op.ID); err != nil {
+			return sent, held, err
+		}
+		sent++
+	}
+	return sent, held, nil
+}
+
+// showPendingChanges lists the outbox with a way to retry, overwrite or
+// discard each queued change.
+func (mw *MainWindow) showPendingChanges() {
+	o := mw.outbox()
+	if o == nil {
+		mw.showInfo("Changes cannot be queued for this profile.")
+		return
+	}
+
+	w := mw.App.NewWindow("Pending changes")
+	var refresh func()
+	refresh = func() {
+		ops := o.Ops()
+		if len(ops) == 0 {
+			w.SetContent(container.NewPadded(widget.NewLabel("No changes are waiting to be sent.")))
+			return
+		}
+		rows := container.NewVBox()
+		for _, op := range ops {
+			title := widget.NewLabelWithStyle(describeOp(op, mw.monitors), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			state := widget.NewLabel("Queued " + formatAge(op.QueuedAt) + ", waiting for the backend.")
+			state.Wrapping = fyne.TextWrapWord
+			actions := container.NewHBox()
+			if op.Error != "" {
+				state.SetText(op.Error)
+				state.Importance = widget.DangerImportance
+				label := "Retry"
+				if op.Conflict {
+					label = "Overwrite"
+				}
+				actions.Add(widget.NewButton(label, func() {
+					if err := o.Force(op.ID); err != nil {
+						dialog.ShowError(err, w)
+					}
+					refresh()
+					mw.rebuildRows()
+					mw.loadMonitors()
+				}))
+			}
+			actions.Add(widget.NewButton("Discard", func() {
+				dialog.ShowConfirm("Discard change", "Discard this change? It will not be sent.", func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := o.Remove(op.ID); err != nil {
+						dialog.ShowError(err, w)
+					}
+					refresh()
+					mw.rebuildRows()
+				}, w)
+			}))
+			rows.Add(container.NewBorder(nil, nil, nil, actions, container.NewVBox(title, state)))
+			rows.Add(widget.NewSeparator())
+		}
+		w.SetContent(container.NewVScroll(rows))
+	}
+	refresh()
+	w.Resize(fyne.NewSize(560, 360))
+	w.Show()
+}
+
+func describeOp(op store.Op, monitors []api.Monitor) string {
+	if op.Kind == store.OpCreate {
+		return "Create " + op.Create.Name
+	}
+	name := fmt.Sprintf("monitor %d", op.MonitorID)
+	for _, m := range monitors {
+		if m.ID == op.MonitorID {
+			name = m.Name
+		}
+	}
+	if op.Kind == store.OpDelete {
+		return "Delete " + name
+	}
+	return "Edit " + name
+}
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+	"watcher-client/store"
+)
+
+// outboxFixture is a fake backend with one monitor, a client for it and an
+// empty outbox.
+type outboxFixture struct {
+	srv    *fake.Server
+	key    string
+	client *api.Client
+	outbox *store.Outbox
+	m      api.Monitor
+}
+
+func newOutboxFixture(t *testing.T) *outboxFixture {
+	t.Helper()
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	key, secret := srv.RegisterInstance("test")
+	s, err := store.Open(t.TempDir(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Outbox(srv.URL, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &outboxFixture{
+		srv:    srv,
+		key:    key,
+		client: api.NewClient(srv.URL, key, secret),
+		outbox: o,
+		m:      srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true}),
+	}
+}
+
+// replay replays the outbox against the backend's current monitors.
+func (f *outboxFixture) replay(t *testing.T) (sent, held int) {
+	t.Helper()
+	current, err := f.client.ListMonitorsContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent, held, err = replayOps(context.Background(), f.client, f.outbox, current)
+	if err != nil {
+		t.Fatalf("replayOps: %v", err)
+	}
+	return sent, held
+}
+
+func (f *outboxFixture) monitor(t *testing.T) (api.Monitor, bool) {
+	t.Helper()
+	for _, m := range f.srv.Monitors(f.key) {
+		if m.ID == f.m.ID {
+			return m, true
+		}
+	}
+	return api.Monitor{}, false
+}
+
+func TestReplaySendsQueuedChanges(t *testing.T) {
+	f := newOutboxFixture(t)
+	name := "Renamed"
+	if err := f.outbox.QueueUpdate(f.m, api.UpdateMonitorReq{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.outbox.QueueCreate(api.NewIdempotencyKey(), api.CreateMonitorReq{Name: "New", URL: "https://new.example.com", FrequencySeconds: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, held := f.replay(t); sent != 2 || held != 0 {
+		t.Fatalf("sent %d, held %d; want 2, 0", sent, held)
+	}
+	if m, _ := f.monitor(t); m.Name != "Renamed" {
+		t.Errorf("monitor name = %q, want Renamed", m.Name)
+	}
+	if n := len(f.srv.Monitors(f.key)); n != 2 {
+		t.Errorf("backend has %d monitors, want 2", n)
+	}
+	if n := f.outbox.Len(); n != 0 {
+		t.Errorf("outbox has %d ops left, want 0", n)
+	}
+}
+
+func TestReplayHoldsUpdateOfChangedMonitor(t *testing.T) {
+	f := newOutboxFixture(t)
+	name := "Ours"
+	if err := f.outbox.QueueUpdate(f.m, api.UpdateMonitorReq{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	theirs := "Theirs"
+	if _, err := f.client.UpdateMonitorContext(context.Background(), f.m.ID, api.UpdateMonitorReq{Name: &theirs}); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, held := f.replay(t); sent != 0 || held != 1 {
+		t.Fatalf("sent %d, held %d; want 0, 1", sent, held)
+	}
+	ops := f.outbox.Ops()
+	if len(ops) != 1 || !ops[0].Conflict || ops[0].Error == "" {
+		t.Fatalf("ops = %+v, want the update held as a conflict", ops)
+	}
+	if m, _ := f.monitor(t); m.Name != "Theirs" {
+		t.Errorf("monitor name = %q, want the backend's change kept", m.Name)
+	}
+
+	// A held op stays held on later replays until it is forced.
+	if sent, held := f.replay(t); sent != 0 || held != 1 {
+		t.Fatalf("second replay: sent %d, held %d; want 0, 1", sent, held)
+	}
+	if err := f.outbox.Force(ops[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if sent, held := f.replay(t); sent != 1 || held != 0 {
+		t.Fatalf("forced replay: sent %d, held %d; want 1, 0", sent, held)
+	}
+	if m, _ := f.monitor(t); m.Name != "Ours" {
+		t.Errorf("monitor name = %q, want the forced change", m.Name)
+	}
+}
+
+func TestReplayHoldsUpdateOfDeletedMonitor(t *testing.T) {
+	f := newOutboxFixture(t)
+	active := false
+	if err := f.outbox.QueueUpdate(f.m, api.UpdateMonitorReq{Active: &active}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.client.DeleteMonitorContext(context.Background(), f.m.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, held := f.replay(t); sent != 0 || held != 1 {
+		t.Fatalf("sent %d, held %d; want 0, 1", sent, held)
+	}
+	if ops := f.outbox.Ops(); len(ops) != 1 || !ops[0].Conflict {
+		t.Fatalf("ops = %+v, want the update held as a conflict", ops)
+	}
+}
+
+func TestReplayDeletes(t *testing.T) {
+	f := newOutboxFixture(t)
+	if err := f.outbox.QueueDelete(f.m); err != nil {
+		t.Fatal(err)
+	}
+	if sent, held := f.replay(t); sent != 1 || held != 0 {
+		t.Fatalf("sent %d, held %d; want 1, 0", sent, held)
+	}
+	if _, ok := f.monitor(t); ok {
+		t.Error("monitor still exists after replaying its delete")
+	}
+}
+
+func TestReplayDeleteOfDeletedMonitorIsDone(t *testing.T) {
+	f := newOutboxFixture(t)
+	if err := f.outbox.QueueDelete(f.m); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.client.DeleteMonitorContext(context.Background(), f.m.ID); err != nil {
+		t.Fatal(err)
+	}
+	if sent, held := f.replay(t); sent != 1 || held != 0 {
+		t.Fatalf("sent %d, held %d; want 1, 0", sent, held)
+	}
+	if n := f.outbox.Len(); n != 0 {
+		t.Errorf("outbox has %d ops left, want 0", n)
+	}
+}
+
+func TestReplayOfAppliedCreateDoesNotDuplicate(t *testing.T) {
+	f := newOutboxFixture(t)
+	key := api.NewIdempotencyKey()
+	req := api.CreateMonitorReq{Name: "New", URL: "https://new.example.com", FrequencySeconds: 60}
+	// The first attempt reached the backend but its answer was lost, so the
+	// create was queued under the key it was sent with.
+	created, err := f.client.CreateMonitorOnce(context.Background(), key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.outbox.QueueCreate(key, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, held := f.replay(t); sent != 1 || held != 0 {
+		t.Fatalf("sent %d, held %d; want 1, 0", sent, held)
+	}
+	var named int
+	for _, m := range f.srv.Monitors(f.key) {
+		if m.Name == "New" {
+			named++
+			if m.ID != created.ID {
+				t.Errorf("replay created monitor %d, want %d", m.ID, created.ID)
+			}
+		}
+	}
+	if named != 1 {
+		t.Errorf("backend has %d monitors named New, want 1", named)
+	}
+}
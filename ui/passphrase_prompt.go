@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowPassphrasePrompt asks for the passphrase protecting the secret file.
+// When creating is true the file does not exist yet and the passphrase is
+// asked twice. onUnlock's error is shown inline so the user can retry.
+func ShowPassphrasePrompt(a fyne.App, creating bool, onUnlock func(passphrase string) error) fyne.Window {
+	w := a.NewWindow("Watcher – Unlock secrets")
+
+	intro := "No system keyring is available, so instance secrets are kept in an encrypted file. Enter its passphrase to continue."
+	if creating {
+		intro = "No system keyring is available, so instance secrets will be kept in an encrypted file. Choose a passphrase to protect it."
+	}
+	introLabel := widget.NewLabel(intro)
+	introLabel.Wrapping = fyne.TextWrapWord
+
+	passEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+
+	items := []*widget.FormItem{widget.NewFormItem("Passphrase", passEntry)}
+	if creating {
+		items = append(items, widget.NewFormItem("Confirm", confirmEntry))
+	}
+
+	var unlockBtn *widget.Button
+	submit := func() {
+		if passEntry.Text == "" {
+			status.SetText("Please enter a passphrase")
+			return
+		}
+		if creating && passEntry.Text != confirmEntry.Text {
+			status.SetText("Passphrases do not match")
+			return
+		}
+		unlockBtn.Disable()
+		if err := onUnlock(passEntry.Text); err != nil {
+			unlockBtn.Enable()
+			status.SetText(err.Error())
+			return
+		}
+		w.Close()
+	}
+	unlockBtn = widget.NewButton("Unlock", submit)
+	unlockBtn.Importance = widget.HighImportance
+	passEntry.OnSubmitted = func(string) { submit() }
+	confirmEntry.OnSubmitted = func(string) { submit() }
+
+	w.SetContent(container.NewVBox(
+		introLabel,
+		widget.NewForm(items...),
+		status,
+		container.NewHBox(unlockBtn),
+	))
+	w.Resize(fyne.NewSize(460, 240))
+	w.CenterOnScreen()
+	w.Show()
+	w.Canvas().Focus(passEntry)
+	return w
+}
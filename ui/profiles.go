@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/config"
+)
+
+const defaultWindowTitle = "Watcher – Desktop Client"
+
+func (mw *MainWindow) activeProfile() *config.Profile {
+	return mw.Config.Profile(mw.Config.ActiveProfile)
+}
+
+func (mw *MainWindow) updateTitle() {
+	title := defaultWindowTitle
+	if len(mw.Config.Profiles) > 1 {
+		title += " [" + mw.Config.ActiveProfile + "]"
+	}
+	switch total := len(mw.allRows); {
+	case total == 0:
+	case mw.matched == total:
+		title += " (" + plural(total, "monitor") + ")"
+	default:
+		title += fmt.Sprintf(" (%d of %s)", mw.matched, plural(total, "monitor"))
+	}
+	mw.Window.SetTitle(title)
+}
+
+func (mw *MainWindow) buildProfileSelect() *widget.Select {
+	sel := widget.NewSelect(mw.Config.ProfileNames(), nil)
+	sel.SetSelected(mw.Config.ActiveProfile)
+	sel.OnChanged = func(name string) {
+		if name == mw.Config.ActiveProfile {
+			return
+		}
+		if err := mw.switchProfile(name); err != nil {
+			mw.showError("Could not switch to profile '" + name + "': " + describeError(err))
+			sel.SetSelected(mw.Config.ActiveProfile)
+		}
+	}
+	return sel
+}
+
+// switchProfile points the window at another backend profile. Profiles
+// without credentials go through the setup wizard first.
+func (mw *MainWindow) switchProfile(name string) error {
+	p, ok := mw.Config.Profiles[name]
+	if !ok || !p.HasCredentials() {
+		mw.profileSelect.SetSelected(mw.Config.ActiveProfile)
+		ShowSetupWizard(mw.App, mw.Config, name, config.DefaultBackendURL, mw.onProfileReady)
+		return nil
+	}
+
+	client := api.NewClient(p.BackendURL, p.InstanceKey, p.InstanceSecret)
+	client.OnUnauthorized = mw.handleUnauthorized
+	mw.Client = client
+	mw.offline.reset()
+	mw.live.start()
+	mw.notify.reset()
+	mw.tray.reset()
+	mw.stats = nil
+	mw.collapsedTags = make(map[uint64]bool)
+	clear(mw.checked)
+	mw.setMonitors(nil)
+	mw.setTags(nil)
+	mw.Config.ActiveProfile = name
+	if err := config.Save(mw.Config); err != nil {
+		mw.showError("Failed to save config: " + err.Error())
+	}
+
+	mw.profileSelect.Options = mw.Config.ProfileNames()
+	mw.profileSelect.SetSelected(name)
+	mw.updateTitle()
+	mw.loadMonitors()
+	return nil
+}
+
+func (mw *MainWindow) showNewProfileDialog() {
+	ShowSetupWizard(mw.App, mw.Config, "", config.DefaultBackendURL, mw.onProfileReady)
+}
+
+func (mw *MainWindow) onProfileReady(name string) {
+	if err := mw.switchProfile(name); err != nil {
+		mw.showError("Could not switch to profile '" + name + "': " + describeError(err))
+	}
+}
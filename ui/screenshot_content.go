@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"bytes"
+	"errors"
 	"image"
 	"image/draw"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -27,25 +30,56 @@ func (l *absoluteLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
 	return l.min
 }
 
-func buildScreenshotContent(c api.ChangeEvent) fyne.CanvasObject {
+func buildScreenshotContent(l *assetLoader, c api.ChangeEvent) fyne.CanvasObject {
 	if c.ScreenshotDiff == nil || *c.ScreenshotDiff == "" {
 		return widget.NewLabel("No screenshot diff available")
 	}
+	diffURL := *c.ScreenshotDiff
+	p := newAssetProgress("Screenshot diff")
+	return asyncContent(l.ctx, progressPlaceholder(p), "Failed to load diff image: ", func() (func() fyne.CanvasObject, error) {
+		tiles, size, err := loadScreenshotTiles(l, diffURL, p)
+		if err != nil {
+			return nil, err
+		}
+		return func() fyne.CanvasObject {
+			return buildTiledImage(tiles, size)
+		}, nil
+	})
+}
+
+type screenshotTile struct {
+	img  *image.RGBA
+	x, y int
+}
 
-	uri, err := storage.ParseURI(*c.ScreenshotDiff)
+// loadScreenshotTiles downloads and decodes an image and cuts it into tiles
+// small enough for the renderer. It only touches the UI through p.
+func loadScreenshotTiles(l *assetLoader, rawURL string, p *assetProgress) ([]screenshotTile, fyne.Size, error) {
+	uri, err := storage.ParseURI(rawURL)
 	if err != nil {
-		return widget.NewLabel("Failed to parse diff image URI")
+		return nil, fyne.Size{}, errors.New("could not parse the image URI")
 	}
 
-	rc, err := storage.Reader(uri)
-	if err != nil {
-		return widget.NewLabel("Failed to open diff image")
+	var rc io.ReadCloser
+	switch uri.Scheme() {
+	case "http", "https":
+		data, err := l.fetch(uri.String(), assetTimeout, p)
+		if err != nil {
+			return nil, fyne.Size{}, err
+		}
+		rc = io.NopCloser(bytes.NewReader(data))
+	default:
+		rc, err = storage.Reader(uri)
+		if err != nil {
+			return nil, fyne.Size{}, err
+		}
 	}
 	defer rc.Close()
 
+	p.setStatus("decoding…")
 	src, _, err := image.Decode(rc)
 	if err != nil {
-		return widget.NewLabel("Failed to decode diff image")
+		return nil, fyne.Size{}, errors.New("could not decode the image")
 	}
 
 	b := src.Bounds()
@@ -56,8 +90,7 @@ func buildScreenshotContent(c api.ChangeEvent) fyne.CanvasObject {
 
 	const tileSize = 512
 
-	tiles := container.New(&absoluteLayout{min: fullSize})
-
+	var tiles []screenshotTile
 	for y := 0; y < b.Dy(); y += tileSize {
 		for x := 0; x < b.Dx(); x += tileSize {
 			w := min(tileSize, b.Dx()-x)
@@ -67,16 +100,23 @@ func buildScreenshotContent(c api.ChangeEvent) fyne.CanvasObject {
 			srcPt := image.Point{X: b.Min.X + x, Y: b.Min.Y + y}
 			draw.Draw(tile, tile.Bounds(), rgba, srcPt, draw.Src)
 
-			im := canvas.NewImageFromImage(tile)
-			im.FillMode = canvas.ImageFillOriginal
-			im.Resize(fyne.NewSize(float32(w), float32(h)))
-			im.Move(fyne.NewPos(float32(x), float32(y)))
-
-			tiles.Add(im)
+			tiles = append(tiles, screenshotTile{img: tile, x: x, y: y})
 		}
 	}
+	return tiles, fullSize, nil
+}
 
-	return container.NewScroll(tiles)
+func buildTiledImage(tiles []screenshotTile, fullSize fyne.Size) fyne.CanvasObject {
+	content := container.New(&absoluteLayout{min: fullSize})
+	for _, t := range tiles {
+		b := t.img.Bounds()
+		im := canvas.NewImageFromImage(t.img)
+		im.FillMode = canvas.ImageFillOriginal
+		im.Resize(fyne.NewSize(float32(b.Dx()), float32(b.Dy())))
+		im.Move(fyne.NewPos(float32(t.x), float32(t.y)))
+		content.Add(im)
+	}
+	return container.NewScroll(content)
 }
 
 func min(a, b int) int {
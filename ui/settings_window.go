@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/cache"
+	"watcher-client/config"
+)
+
+// ShowSettingsWindow shows the background settings of cfg and local storage
+// used by the client. onSyncChanged is called after the sync interval was
+// changed. assets may be nil if the asset cache could not be opened.
+func ShowSettingsWindow(a fyne.App, assets *cache.Cache, cfg *config.InstanceConfig, onSyncChanged func()) {
+	w := a.NewWindow("Watcher – Settings")
+	background := buildBackgroundSettings(a, w, cfg, onSyncChanged)
+
+	heading := widget.NewLabelWithStyle("Asset cache", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	help := widget.NewLabel("Snapshots, diffs and screenshots of changes you have opened are kept on disk so they do not have to be downloaded again.")
+	help.Wrapping = fyne.TextWrapWord
+
+	if assets == nil {
+		unavailable := widget.NewLabel("The asset cache could not be opened, so assets are downloaded every time.")
+		unavailable.Wrapping = fyne.TextWrapWord
+		w.SetContent(container.NewVBox(background, widget.NewSeparator(), heading, help, unavailable))
+		w.Resize(fyne.NewSize(480, 360))
+		w.Show()
+		return
+	}
+
+	location := widget.NewLabel(assets.Dir())
+	location.Wrapping = fyne.TextWrapBreak
+	usage := widget.NewLabel("")
+	refresh := func() {
+		used, n := assets.Usage()
+		usage.SetText(fmt.Sprintf("%s of %s (%d assets)", formatBytes(used), formatBytes(assets.MaxBytes()), n))
+	}
+	refresh()
+
+	purgeBtn := widget.NewButton("Purge cache", func() {
+		dialog.ShowConfirm("Purge cache", "Delete all cached assets? They will be downloaded again when needed.", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := assets.Purge(); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to purge cache: %w", err), w)
+			}
+			refresh()
+		}, w)
+	})
+	purgeBtn.Importance = widget.DangerImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("Location", location),
+		widget.NewFormItem("Usage", usage),
+	)
+	w.SetContent(container.NewVBox(background, widget.NewSeparator(), heading, help, form, container.NewHBox(purgeBtn)))
+	w.Resize(fyne.NewSize(480, 420))
+	w.Show()
+}
+
+// syncIntervals are the background sync intervals offered in the settings.
+var syncIntervals = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour}
+
+func buildBackgroundSettings(a fyne.App, w fyne.Window, cfg *config.InstanceConfig, onSyncChanged func()) fyne.CanvasObject {
+	heading := widget.NewLabelWithStyle("Background", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	save := func() {
+		if err := config.Save(cfg); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save config: %w", err), w)
+		}
+	}
+
+	keepRunning := widget.NewCheck("Keep running in the system tray when the window is closed", func(on bool) {
+		cfg.RunInBackground = on
+		save()
+	})
+	keepRunning.SetChecked(cfg.RunInBackground)
+	if _, ok := a.(desktop.App); !ok {
+		keepRunning.SetChecked(false)
+		keepRunning.Disable()
+	}
+
+	var labels []string
+	for _, d := range syncIntervals {
+		labels = append(labels, formatInterval(d))
+	}
+	interval := widget.NewSelect(labels, nil)
+	interval.SetSelected(formatInterval(cfg.SyncInterval()))
+	interval.OnChanged = func(label string) {
+		i := slices.Index(labels, label)
+		if i < 0 || syncIntervals[i] == cfg.SyncInterval() {
+			return
+		}
+		cfg.SyncIntervalSeconds = int(syncIntervals[i] / time.Second)
+		save()
+		onSyncChanged()
+	}
+
+	return container.NewVBox(heading, keepRunning, widget.NewForm(widget.NewFormItem("Sync every", interval)))
+}
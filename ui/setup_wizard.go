@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+	"watcher-client/config"
+)
+
+const (
+	setupRegisterNew = "Register this device as a new instance"
+	setupUseExisting = "Use existing instance credentials"
+)
+
+// ShowSetupWizard walks the user through connecting profile name to a
+// backend: choose the URL, test it, then register or paste credentials. The
+// profile is only written to cfg and saved once setup succeeds, after which
+// onDone is called with the profile name. An empty name asks the user for one.
+func ShowSetupWizard(a fyne.App, cfg *config.InstanceConfig, name, defaultURL string, onDone func(name string)) fyne.Window {
+	w := a.NewWindow("Watcher – Setup")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.SetOnClosed(cancel)
+
+	backendURL := defaultURL
+	p, ok := cfg.Profiles[name]
+	if ok && p.BackendURL != "" {
+		backendURL = p.BackendURL
+	}
+
+	// Step 1: profile name and backend URL.
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("staging")
+	nameEntry.SetText(name)
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://watcher.example.com")
+	urlEntry.SetText(backendURL)
+	connStatus := widget.NewLabel("")
+	connStatus.Wrapping = fyne.TextWrapWord
+	if ok && p.SecretMissing {
+		connStatus.SetText("The instance secret of this profile is missing from the secret store. " +
+			"Register this device again, or link the instance key with its secret.")
+	}
+	testBtn := widget.NewButton("Test connection", nil)
+	nextBtn := widget.NewButton("Next", nil)
+	nextBtn.Importance = widget.HighImportance
+	nextBtn.Disable()
+
+	urlItems := []*widget.FormItem{widget.NewFormItem("Backend URL", urlEntry)}
+	if name == "" {
+		urlItems = append([]*widget.FormItem{widget.NewFormItem("Profile name", nameEntry)}, urlItems...)
+	}
+	connectStep := container.NewVBox(
+		widget.NewLabelWithStyle("Connect to a Watcher backend", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewForm(urlItems...),
+		testBtn,
+		connStatus,
+		container.NewHBox(nextBtn),
+	)
+
+	// Step 2: register or link credentials.
+	instanceEntry := widget.NewEntry()
+	instanceEntry.SetText(defaultInstanceName())
+	keyEntry := widget.NewEntry()
+	keyEntry.SetPlaceHolder("Instance key")
+	if ok && p.SecretMissing {
+		keyEntry.SetText(p.InstanceKey)
+	}
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("Instance secret")
+	registerForm := widget.NewForm(widget.NewFormItem("Instance name", instanceEntry))
+	linkForm := widget.NewForm(
+		widget.NewFormItem("Key", keyEntry),
+		widget.NewFormItem("Secret", secretEntry),
+	)
+	linkForm.Hide()
+	mode := widget.NewRadioGroup([]string{setupRegisterNew, setupUseExisting}, func(choice string) {
+		if choice == setupUseExisting {
+			registerForm.Hide()
+			linkForm.Show()
+		} else {
+			linkForm.Hide()
+			registerForm.Show()
+		}
+	})
+	mode.SetSelected(setupRegisterNew)
+	mode.Required = true
+	finishStatus := widget.NewLabel("")
+	finishStatus.Wrapping = fyne.TextWrapWord
+	backBtn := widget.NewButton("Back", nil)
+	finishBtn := widget.NewButton("Finish", nil)
+	finishBtn.Importance = widget.HighImportance
+
+	credentialsStep := container.NewVBox(
+		widget.NewLabelWithStyle("Identify this device", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		mode,
+		registerForm,
+		linkForm,
+		finishStatus,
+		container.NewHBox(backBtn, finishBtn),
+	)
+
+	checkedURL := ""
+	urlEntry.OnChanged = func(string) {
+		checkedURL = ""
+		nextBtn.Disable()
+	}
+
+	testBtn.OnTapped = func() {
+		u := strings.TrimRight(strings.TrimSpace(urlEntry.Text), "/")
+		if err := validateBackendURL(u); err != nil {
+			connStatus.SetText(err.Error())
+			return
+		}
+		testBtn.Disable()
+		connStatus.SetText("Connecting to " + u + "…")
+		go func() {
+			err := api.CheckBackend(ctx, u)
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(func() {
+				testBtn.Enable()
+				if err != nil {
+					connStatus.SetText("Could not reach the backend: " + describeError(err))
+					return
+				}
+				checkedURL = u
+				connStatus.SetText("Connected to " + u)
+				nextBtn.Enable()
+			})
+		}()
+	}
+
+	nextBtn.OnTapped = func() {
+		n := strings.TrimSpace(nameEntry.Text)
+		if n == "" {
+			connStatus.SetText("Please enter a profile name")
+			return
+		}
+		if name == "" {
+			if _, exists := cfg.Profiles[n]; exists {
+				connStatus.SetText("A profile named '" + n + "' already exists")
+				return
+			}
+		}
+		w.SetContent(credentialsStep)
+	}
+	backBtn.OnTapped = func() {
+		w.SetContent(connectStep)
+	}
+
+	finishBtn.OnTapped = func() {
+		profileName := strings.TrimSpace(nameEntry.Text)
+		u := checkedURL
+		useExisting := mode.Selected == setupUseExisting
+		instanceName := strings.TrimSpace(instanceEntry.Text)
+		key := strings.TrimSpace(keyEntry.Text)
+		secret := strings.TrimSpace(secretEntry.Text)
+
+		if useExisting && (key == "" || secret == "") {
+			finishStatus.SetText("Both key and secret are required")
+			return
+		}
+		if !useExisting && instanceName == "" {
+			instanceName = defaultInstanceName()
+		}
+
+		finishBtn.Disable()
+		backBtn.Disable()
+		finishStatus.SetText("Setting up…")
+		go func() {
+			var err error
+			if useExisting {
+				probe := api.NewClient(u, key, secret)
+				_, err = probe.ListMonitorsContext(ctx)
+			} else {
+				key, secret, err = api.RegisterInstanceContext(ctx, u, instanceName)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(func() {
+				finishBtn.Enable()
+				backBtn.Enable()
+				if err != nil {
+					finishStatus.SetText("Setup failed: " + describeError(err))
+					return
+				}
+				p := cfg.Profile(profileName)
+				p.BackendURL = u
+				p.InstanceKey = key
+				p.InstanceSecret = secret
+				p.SecretMissing = false
+				cfg.ActiveProfile = profileName
+				if err := config.Save(cfg); err != nil {
+					finishStatus.SetText("Connected, but saving the config failed: " + err.Error())
+					return
+				}
+				onDone(profileName)
+				w.Close()
+			})
+		}()
+	}
+
+	w.SetContent(connectStep)
+	w.Resize(fyne.NewSize(520, 360))
+	w.CenterOnScreen()
+	w.Show()
+	return w
+}
+
+func validateBackendURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("Please enter an http:// or https:// backend URL")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"context"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// statusBar reports background work inline at the bottom of a window: a
+// spinner while requests are in flight, and errors with an optional retry
+// instead of modal dialogs. All methods must run on the UI goroutine.
+type statusBar struct {
+	root     *fyne.Container
+	activity *widget.Activity
+	label    *widget.Label
+	retryBtn *widget.Button
+	closeBtn *widget.Button
+
+	pending int
+	onRetry func()
+}
+
+func newStatusBar() *statusBar {
+	s := &statusBar{
+		activity: widget.NewActivity(),
+		label:    widget.NewLabel(""),
+	}
+	s.label.Truncation = fyne.TextTruncateEllipsis
+	s.retryBtn = widget.NewButtonWithIcon("Retry", theme.ViewRefreshIcon(), func() {
+		retry := s.onRetry
+		s.clear()
+		if retry != nil {
+			retry()
+		}
+	})
+	s.closeBtn = widget.NewButtonWithIcon("", theme.CancelIcon(), s.clear)
+	s.closeBtn.Importance = widget.LowImportance
+
+	s.root = container.NewBorder(nil, nil, s.activity, container.NewHBox(s.retryBtn, s.closeBtn), s.label)
+	s.clear()
+	return s
+}
+
+// busy shows msg with a spinner until the matching call to done.
+func (s *statusBar) busy(msg string) {
+	s.pending++
+	s.onRetry = nil
+	s.retryBtn.Hide()
+	s.closeBtn.Hide()
+	s.label.Importance = widget.MediumImportance
+	s.label.SetText(msg)
+	s.activity.Show()
+	s.activity.Start()
+	s.root.Show()
+}
+
+func (s *statusBar) done() {
+	if s.pending > 0 {
+		s.pending--
+	}
+	if s.pending == 0 {
+		s.activity.Stop()
+		s.activity.Hide()
+		if s.onRetry == nil && !s.closeBtn.Visible() {
+			s.root.Hide()
+		}
+	}
+}
+
+// fail shows an error until dismissed; retry, if not nil, is offered as a button.
+func (s *statusBar) fail(msg string, retry func()) {
+	s.onRetry = retry
+	s.label.Importance = widget.DangerImportance
+	s.label.SetText(msg)
+	s.closeBtn.Show()
+	if retry != nil {
+		s.retryBtn.Show()
+	} else {
+		s.retryBtn.Hide()
+	}
+	s.root.Show()
+}
+
+// note shows an informational message until dismissed.
+func (s *statusBar) note(msg string) {
+	s.onRetry = nil
+	s.label.Importance = widget.MediumImportance
+	s.label.SetText(msg)
+	s.retryBtn.Hide()
+	s.closeBtn.Show()
+	s.root.Show()
+}
+
+func (s *statusBar) clear() {
+	s.onRetry = nil
+	s.label.SetText("")
+	s.retryBtn.Hide()
+	s.closeBtn.Hide()
+	if s.pending == 0 {
+		s.activity.Stop()
+		s.activity.Hide()
+		s.root.Hide()
+	}
+}
+
+// inlineError is shown in a content area whose data failed to load.
+func inlineError(msg string, retry func()) fyne.CanvasObject {
+	label := widget.NewLabel(msg)
+	label.Wrapping = fyne.TextWrapWord
+	label.Importance = widget.DangerImportance
+	if retry == nil {
+		return label
+	}
+	return container.NewVBox(label, container.NewHBox(widget.NewButtonWithIcon("Retry", theme.ViewRefreshIcon(), retry)))
+}
+
+// asyncContent returns a container that shows the object made by loading
+// while load runs in the background, then the object built by the function
+// load returns. The build step runs on the UI goroutine so load only has to do
+// I/O and decoding. If load fails, failMsg and the error are shown with a
+// retry button.
+func asyncContent(ctx context.Context, loading func() fyne.CanvasObject, failMsg string, load func() (func() fyne.CanvasObject, error)) *fyne.Container {
+	holder := container.NewStack()
+	var start func()
+	start = func() {
+		holder.Objects = []fyne.CanvasObject{loading()}
+		holder.Refresh()
+		go func() {
+			build, err := load()
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(func() {
+				if err != nil {
+					holder.Objects = []fyne.CanvasObject{inlineError(failMsg+describeError(err), start)}
+				} else {
+					holder.Objects = []fyne.CanvasObject{build()}
+				}
+				holder.Refresh()
+			})
+		}()
+	}
+	start()
+	return holder
+}
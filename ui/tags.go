@@ -0,0 +1,390 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"watcher-client/api"
+)
+
+// tagPalette are the colors offered for tags.
+var tagPalette = []viewOption{
+	{"", "Default"},
+	{"#e5484d", "Red"},
+	{"#f76b15", "Orange"},
+	{"#ffc53d", "Yellow"},
+	{"#30a46c", "Green"},
+	{"#12a594", "Teal"},
+	{"#3e63dd", "Blue"},
+	{"#8e4ec6", "Purple"},
+	{"#d6409f", "Pink"},
+}
+
+// rowGroup heads the monitors of a tag in the grouped view. tag is nil for
+// the monitors without tags.
+type rowGroup struct {
+	tag   *api.Tag
+	count int
+}
+
+func (g *rowGroup) id() uint64 {
+	if g.tag == nil {
+		return 0
+	}
+	return g.tag.ID
+}
+
+func tagNames(r monitorRow) string {
+	var names []string
+	for _, t := range r.tags {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// tagsOf returns the known tags among ids, in the order of mw.tags.
+func (mw *MainWindow) tagsOf(ids []uint64) []api.Tag {
+	var out []api.Tag
+	for _, t := range mw.tags {
+		if slices.Contains(ids, t.ID) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// tagColors returns the badge and text colors of a tag, or of the
+// untagged group for nil.
+func tagColors(t *api.Tag) (color.Color, color.Color) {
+	if t == nil {
+		return theme.Color(theme.ColorNameDisabledButton), theme.Color(theme.ColorNameForeground)
+	}
+	bg, ok := parseHexColor(t.Color)
+	if !ok {
+		return theme.Color(theme.ColorNamePrimary), theme.Color(theme.ColorNameForegroundOnPrimary)
+	}
+	// Dark text on light colors, white text on dark ones.
+	if 299*int(bg.R)+587*int(bg.G)+114*int(bg.B) > 150000 {
+		return bg, color.NRGBA{A: 0xff}
+	}
+	return bg, color.White
+}
+
+func parseHexColor(s string) (color.NRGBA, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.NRGBA{}, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+// setTags replaces the known tags, keeping them sorted by name.
+func (mw *MainWindow) setTags(tags []api.Tag) {
+	mw.tags = slices.Clone(tags)
+	slices.SortFunc(mw.tags, func(a, b api.Tag) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	mw.rebuildRows()
+	if mw.tagsRefresh != nil {
+		mw.tagsRefresh()
+	}
+}
+
+// upsertTag applies a tag the backend reported as created or changed.
+func (mw *MainWindow) upsertTag(t api.Tag) {
+	tags := slices.DeleteFunc(slices.Clone(mw.tags), func(o api.Tag) bool { return o.ID == t.ID })
+	mw.setTags(append(tags, t))
+	mw.saveTags()
+}
+
+// removeTag drops a deleted tag, and takes it off the monitors as the
+// backend did.
+func (mw *MainWindow) removeTag(id uint64) {
+	for i := range mw.monitors {
+		if m := &mw.monitors[i]; m.HasTag(id) {
+			m.TagIDs = slices.DeleteFunc(slices.Clone(m.TagIDs), func(t uint64) bool { return t == id })
+		}
+	}
+	mw.setTags(slices.DeleteFunc(slices.Clone(mw.tags), func(t api.Tag) bool { return t.ID == id }))
+	mw.saveMirror()
+	mw.saveTags()
+}
+
+// groupRows puts rows under a header for each tag they have, in tag order,
+// followed by those without tags. Monitors with several tags are listed
+// under each. The monitors of folded tags are left out.
+func (mw *MainWindow) groupRows(rows []monitorRow) []monitorRow {
+	var out []monitorRow
+	add := func(g *rowGroup, members []monitorRow) {
+		if len(members) == 0 {
+			return
+		}
+		g.count = len(members)
+		out = append(out, monitorRow{group: g})
+		if !mw.collapsedTags[g.id()] {
+			out = append(out, members...)
+		}
+	}
+	for i := range mw.tags {
+		t := &mw.tags[i]
+		var members []monitorRow
+		for _, r := range rows {
+			if r.HasTag(t.ID) {
+				members = append(members, r)
+			}
+		}
+		add(&rowGroup{tag: t}, members)
+	}
+	var untagged []monitorRow
+	for _, r := range rows {
+		if len(r.tags) == 0 {
+			untagged = append(untagged, r)
+		}
+	}
+	add(&rowGroup{}, untagged)
+	return out
+}
+
+func (mw *MainWindow) toggleGroup(g *rowGroup) {
+	mw.collapsedTags[g.id()] = !mw.collapsedTags[g.id()]
+	mw.rebuildRows()
+}
+
+func (mw *MainWindow) updateGroupCell(cell *monitorCell, g *rowGroup, key string) {
+	switch key {
+	case "status":
+		name := "No tag"
+		if g.tag != nil {
+			name = g.tag.Name
+		}
+		bg, fg := tagColors(g.tag)
+		cell.showBadge(name, bg, fg)
+	case "name":
+		marker := "▾ "
+		if mw.collapsedTags[g.id()] {
+			marker = "▸ "
+		}
+		cell.showText(marker+plural(g.count, "monitor"), true)
+	default:
+		cell.showText("", false)
+	}
+}
+
+// tagPicker returns checks for the known tags with those in selected
+// checked, and a func returning the IDs checked. It returns nil if there
+// are no tags.
+func (mw *MainWindow) tagPicker(selected []uint64) (fyne.CanvasObject, func() []uint64) {
+	tags := slices.Clone(mw.tags)
+	if len(tags) == 0 {
+		return nil, func() []uint64 { return nil }
+	}
+	var names []string
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	group := widget.NewCheckGroup(names, nil)
+	group.Horizontal = true
+	for _, t := range tags {
+		if slices.Contains(selected, t.ID) {
+			group.Selected = append(group.Selected, t.Name)
+		}
+	}
+	picked := func() []uint64 {
+		ids := []uint64{}
+		for _, t := range tags {
+			if slices.Contains(group.Selected, t.Name) {
+				ids = append(ids, t.ID)
+			}
+		}
+		return ids
+	}
+	return group, picked
+}
+
+// sameTagIDs reports whether a and b hold the same tags in any order.
+func sameTagIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// showTags opens the tag manager: tags can be created, renamed, recolored
+// and deleted there, and the monitors of a tag paused or resumed at once.
+func (mw *MainWindow) showTags() {
+	if mw.tagsWindow != nil {
+		mw.tagsWindow.Show()
+		mw.tagsWindow.RequestFocus()
+		return
+	}
+	w := mw.App.NewWindow("Tags")
+	mw.tagsWindow = w
+
+	refresh := func() {
+		newBtn := widget.NewButtonWithIcon("New tag…", theme.ContentAddIcon(), func() { mw.showTagForm(w, nil, nil, nil) })
+		top := container.NewBorder(nil, nil, nil, newBtn, widget.NewLabel("Group monitors by tag, and pause or resume all monitors of a tag."))
+		if len(mw.tags) == 0 {
+			w.SetContent(container.NewBorder(top, nil, nil, nil, container.NewPadded(widget.NewLabel("No tags yet."))))
+			return
+		}
+		rows := container.NewVBox()
+		for _, t := range mw.tags {
+			bg, fg := tagColors(&t)
+			swatch := canvas.NewRectangle(bg)
+			swatch.CornerRadius = theme.InputRadiusSize()
+			text := canvas.NewText(t.Name, fg)
+			text.TextStyle.Bold = true
+			badge := container.NewStack(swatch, container.NewPadded(text))
+
+			count := 0
+			for _, r := range mw.allRows {
+				if r.HasTag(t.ID) {
+					count++
+				}
+			}
+			actions := container.NewHBox(
+				widget.NewButton("Edit…", func() { mw.showTagForm(w, &t, nil, nil) }),
+				widget.NewButton("Pause all", func() { mw.setActive(w, false, &t) }),
+				widget.NewButton("Resume all", func() { mw.setActive(w, true, &t) }),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { mw.confirmDeleteTag(w, t, count) }),
+			)
+			rows.Add(container.NewBorder(nil, nil, container.NewCenter(badge), actions, widget.NewLabel(plural(count, "monitor"))))
+			rows.Add(widget.NewSeparator())
+		}
+		w.SetContent(container.NewBorder(top, nil, nil, nil, container.NewVScroll(rows)))
+	}
+	refresh()
+
+	stop := mw.live.listen(func(ev api.Event) {
+		switch ev.Type {
+		case api.EventMonitorCreated, api.EventMonitorUpdated, api.EventMonitorDeleted:
+			// Tag counts may have changed; tag events refresh via setTags.
+			refresh()
+		}
+	})
+	mw.tagsRefresh = refresh
+	w.SetOnClosed(func() {
+		stop()
+		mw.tagsWindow, mw.tagsRefresh = nil, nil
+	})
+	w.Resize(fyne.NewSize(620, 360))
+	w.Show()
+}
+
+// showTagForm creates a tag, or edits t if it is not nil. When prev is set
+// the form is refilled with a rejected request and apiErr's field errors are
+// highlighted.
+func (mw *MainWindow) showTagForm(parent fyne.Window, t *api.Tag, prev *api.CreateTagReq, apiErr *api.APIError) {
+	if mw.offline.active {
+		dialog.ShowInformation("Offline", "Tags can only be changed while the backend is reachable.", parent)
+		return
+	}
+	nameEntry := widget.NewEntry()
+	palette := slices.Clone(tagPalette)
+	colorSelect := widget.NewSelect(nil, nil)
+
+	title, confirm := "New tag", "Create"
+	shown := api.CreateTagReq{}
+	if t != nil {
+		title, confirm = "Edit tag – "+t.Name, "Save"
+		shown = api.CreateTagReq{Name: t.Name, Color: t.Color}
+	}
+	if prev != nil {
+		shown = *prev
+	}
+	if !slices.ContainsFunc(palette, func(o viewOption) bool { return o.value == shown.Color }) {
+		// A color set elsewhere that is not in the palette.
+		palette = append(palette, viewOption{shown.Color, shown.Color})
+	}
+	colorSelect.SetOptions(optionLabels(palette))
+	nameEntry.SetText(shown.Name)
+	colorSelect.SetSelected(optionLabel(palette, shown.Color))
+	markFieldError(nameEntry, apiErr, "name")
+
+	form := dialog.NewForm(title, confirm, "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Color", colorSelect),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		req := api.CreateTagReq{Name: strings.TrimSpace(nameEntry.Text), Color: optionValue(palette, colorSelect.Selected)}
+		if req.Name == "" {
+			dialog.ShowError(errors.New("Please enter a name for the tag"), parent)
+			return
+		}
+		var saved *api.Tag
+		mw.background("Saving tag…", func(ctx context.Context) error {
+			var err error
+			if t == nil {
+				saved, err = mw.Client.CreateTag(ctx, req)
+				return err
+			}
+			var upd api.UpdateTagReq
+			if req.Name != t.Name {
+				upd.Name = &req.Name
+			}
+			if req.Color != t.Color {
+				upd.Color = &req.Color
+			}
+			saved, err = mw.Client.UpdateTag(ctx, t.ID, upd)
+			return err
+		}, func(err error) {
+			if apiErr := fieldErrors(err); apiErr != nil {
+				mw.showTagForm(parent, t, &req, apiErr)
+				return
+			}
+			if err != nil {
+				dialog.ShowError(errors.New("Saving the tag failed: "+describeError(err)), parent)
+				return
+			}
+			mw.upsertTag(*saved)
+		})
+	}, parent)
+	form.Resize(fyne.NewSize(380, 220))
+	form.Show()
+}
+
+func (mw *MainWindow) confirmDeleteTag(parent fyne.Window, t api.Tag, count int) {
+	if mw.offline.active {
+		dialog.ShowInformation("Offline", "Tags can only be changed while the backend is reachable.", parent)
+		return
+	}
+	msg := "Delete tag '" + t.Name + "'?"
+	if count > 0 {
+		msg += " It is removed from " + plural(count, "monitor") + "; the monitors are kept."
+	}
+	dialog.ShowConfirm("Delete tag", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		mw.background("Deleting tag…", func(ctx context.Context) error {
+			return mw.Client.DeleteTag(ctx, t.ID)
+		}, func(err error) {
+			if err != nil && !errors.Is(err, api.ErrNotFound) {
+				dialog.ShowError(errors.New("Deleting the tag failed: "+describeError(err)), parent)
+				return
+			}
+			mw.removeTag(t.ID)
+		})
+	}, parent)
+}
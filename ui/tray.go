@@ -0,0 +1,289 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+
+	"watcher-client/api"
+)
+
+// tray is the system tray menu of the main window, on desktops that have
+// one. It counts changes that came in since the user last looked at their
+// monitor and offers shortcuts that work with the window closed. All methods
+// must be called on the UI goroutine.
+type tray struct {
+	mw   *MainWindow
+	desk desktop.App // nil without a system tray
+
+	menu       *fyne.Menu
+	unreadItem *fyne.MenuItem
+	latestItem *fyne.MenuItem
+
+	// unread holds the changes not looked at yet, oldest first.
+	unread    []api.ChangeEvent
+	hintShown bool
+}
+
+func newTray(mw *MainWindow) *tray {
+	t := &tray{mw: mw}
+	mw.live.listen(t.handle)
+	desk, ok := mw.App.(desktop.App)
+	if !ok {
+		return t
+	}
+	t.desk = desk
+
+	t.unreadItem = fyne.NewMenuItem("", nil)
+	t.unreadItem.Disabled = true
+	t.latestItem = fyne.NewMenuItem("Open latest change", t.openLatest)
+	t.menu = fyne.NewMenu("Watcher",
+		t.unreadItem,
+		t.latestItem,
+		fyne.NewMenuItem("Mark all as read", func() { t.markRead(0) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Pause all monitors", func() { mw.setAllActive(false) }),
+		fyne.NewMenuItem("Resume all monitors", func() { mw.setAllActive(true) }),
+		fyne.NewMenuItem("Sync now", mw.sync),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Show Watcher", t.showWindow),
+	)
+	desk.SetSystemTrayMenu(t.menu)
+	t.update()
+	return t
+}
+
+func (t *tray) handle(ev api.Event) {
+	if ev.Type != api.EventChangeDetected || slices.ContainsFunc(t.unread, func(c api.ChangeEvent) bool { return c.ID == ev.Change.ID }) {
+		return
+	}
+	t.unread = append(t.unread, *ev.Change)
+	t.update()
+}
+
+// markRead drops the unread changes of a monitor, or all of them for 0.
+func (t *tray) markRead(monitorID uint64) {
+	t.unread = slices.DeleteFunc(t.unread, func(c api.ChangeEvent) bool {
+		return monitorID == 0 || c.MonitorID == monitorID
+	})
+	t.update()
+}
+
+// reset forgets the unread changes, e.g. after switching profiles.
+func (t *tray) reset() {
+	t.unread = nil
+	t.update()
+}
+
+func (t *tray) update() {
+	if t.desk == nil {
+		return
+	}
+	if n := len(t.unread); n == 0 {
+		t.unreadItem.Label = "No unread changes"
+		t.desk.SetSystemTrayIcon(theme.VisibilityIcon())
+	} else {
+		t.unreadItem.Label = plural(n, "unread change")
+		t.desk.SetSystemTrayIcon(theme.NewErrorThemedResource(theme.VisibilityIcon()))
+	}
+	t.latestItem.Disabled = len(t.unread) == 0
+	t.menu.Refresh()
+}
+
+// openLatest shows the newest unread change. It is also in the File menu,
+// for desktops without a tray.
+func (t *tray) openLatest() {
+	if len(t.unread) == 0 {
+		t.mw.status.note("No unread changes.")
+		return
+	}
+	c := latestChange(t.unread)
+	if m, ok := t.mw.monitorByID(c.MonitorID); ok {
+		t.mw.showChange(c, m)
+	}
+}
+
+func (t *tray) showWindow() {
+	t.mw.Window.Show()
+	t.mw.Window.RequestFocus()
+}
+
+// closeWindow is the close intercept of the main window: with background
+// mode on and a tray to come back from, the window is only hidden.
+func (t *tray) closeWindow() {
+	mw := t.mw
+	if t.desk == nil || !mw.Config.RunInBackground {
+		mw.Window.Close()
+		if t.desk != nil {
+			// A tray keeps the app alive without windows; quit as before.
+			mw.App.Quit()
+		}
+		return
+	}
+	mw.Window.Hide()
+	if !t.hintShown {
+		t.hintShown = true
+		mw.App.SendNotification(fyne.NewNotification("Watcher is still running",
+			"It keeps checking for changes in the background. Use the tray icon to open it again or quit."))
+	}
+}
+
+// startSync reloads the monitors every configured interval, restarting the
+// timer if it was running. Without an event stream new changes are polled
+// for at the same time.
+func (mw *MainWindow) startSync() {
+	if mw.syncCancel != nil {
+		mw.syncCancel()
+	}
+	var ctx context.Context
+	ctx, mw.syncCancel = context.WithCancel(mw.ctx)
+	interval := mw.Config.SyncInterval()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fyne.Do(mw.sync)
+			}
+		}
+	}()
+}
+
+// sync reloads the monitors, which polls for their changes if the backend
+// does not stream them. While offline the offline probe takes care of
+// reloading.
+func (mw *MainWindow) sync() {
+	if mw.offline.active {
+		return
+	}
+	mw.loadMonitors()
+}
+
+// pollChanges looks up the history of freshly loaded monitors seen for the
+// first time, for the change columns and to compare with later. If the
+// backend does not stream events, it also fetches the changes of the others
+// newer than the last one seen of each and applies them as if the stream had
+// reported them. What counts as seen goes by the backend's own timestamps on
+// the changes, so the local clock does not matter.
+func (mw *MainWindow) pollChanges(ms []api.Monitor) {
+	var fresh, poll []uint64
+	since := make(map[uint64]time.Time)
+	for _, m := range ms {
+		if st := mw.stats[m.ID]; st == nil || !st.changesKnown {
+			fresh = append(fresh, m.ID)
+		} else if !mw.live.streaming {
+			poll = append(poll, m.ID)
+			since[m.ID] = st.lastChange
+		}
+	}
+	if len(fresh) == 0 && len(poll) == 0 {
+		return
+	}
+
+	client := mw.Client
+	heads := make(map[uint64]*api.ChangesPage)
+	found := make(map[uint64][]api.ChangeEvent)
+	mw.background("Checking for new changes…", func(ctx context.Context) error {
+		for _, id := range fresh {
+			page, err := client.ListChangesPage(ctx, id, api.ChangesQuery{Limit: 1})
+			if errors.Is(err, api.ErrNotFound) {
+				continue // deleted meanwhile
+			}
+			if err != nil {
+				return err
+			}
+			heads[id] = page
+		}
+		for _, id := range poll {
+			page, err := client.ListChangesPage(ctx, id, api.ChangesQuery{After: since[id], Limit: 100})
+			if errors.Is(err, api.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			found[id] = page.Items
+		}
+		return nil
+	}, func(err error) {
+		if client != mw.Client {
+			return
+		}
+		if err != nil {
+			// Nothing moved, so the next load asks again.
+			if !api.IsUnreachable(err) {
+				mw.status.fail("Checking for new changes failed: "+describeError(err), nil)
+			}
+			return
+		}
+		for id, page := range heads {
+			st := mw.statsOf(id)
+			if st.changesKnown {
+				continue // another load got there first
+			}
+			st.changesKnown = true
+			st.changes = page.Total
+			if len(page.Items) > 0 {
+				st.lastChange = page.Items[0].CreatedAt
+			}
+		}
+		var changes []api.ChangeEvent
+		for id, items := range found {
+			// Another load or the stream may have got some of them already.
+			for _, c := range items {
+				if c.CreatedAt.After(mw.statsOf(id).lastChange) {
+					changes = append(changes, c)
+				}
+			}
+		}
+		slices.SortFunc(changes, func(a, b api.ChangeEvent) int { return a.CreatedAt.Compare(b.CreatedAt) })
+		for _, c := range changes {
+			mw.live.apply(api.Event{Type: api.EventChangeDetected, MonitorID: c.MonitorID, Change: &c})
+		}
+		if len(heads) > 0 {
+			mw.rebuildRows()
+		}
+	})
+}
+
+// setAllActive pauses or resumes every monitor that is not in that state
+// yet, showing the window for the progress and report.
+func (mw *MainWindow) setAllActive(active bool) {
+	mw.tray.showWindow()
+	mw.setActive(mw.Window, active, nil)
+}
+
+// setActive pauses or resumes every monitor that is not in that state yet,
+// or only those tagged with tag if it is not nil, as a bulk action shown on
+// parent.
+func (mw *MainWindow) setActive(parent fyne.Window, active bool, tag *api.Tag) {
+	var targets []api.Monitor
+	for _, r := range mw.allRows {
+		if !checkable(r) || r.Active == active || (tag != nil && !r.HasTag(tag.ID)) {
+			continue
+		}
+		m, _ := mw.monitorByID(r.ID)
+		targets = append(targets, m)
+	}
+	if len(targets) == 0 {
+		scope := "All monitors"
+		if tag != nil {
+			scope += " tagged " + tag.Name
+		}
+		if active {
+			mw.status.note(scope + " are active already.")
+		} else {
+			mw.status.note(scope + " are paused already.")
+		}
+		return
+	}
+	mw.runBulk(parent, activeAction(active), targets)
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"watcher-client/api"
+	"watcher-client/api/fake"
+	"watcher-client/config"
+)
+
+// newTestWindow opens a main window on srv for the instance key, without
+// the event stream if the backend should not have one.
+func newTestWindow(t *testing.T, srv *fake.Server, key, secret string, stream bool) *MainWindow {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	url := srv.URL
+	if !stream {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/events" {
+				http.NotFound(w, r)
+				return
+			}
+			srv.Config.Handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(proxy.Close)
+		url = proxy.URL
+	}
+
+	a := test.NewApp()
+	cfg := &config.InstanceConfig{
+		ActiveProfile: config.DefaultProfile,
+		Profiles: map[string]*config.Profile{
+			config.DefaultProfile: {BackendURL: url, InstanceKey: key, InstanceSecret: secret},
+		},
+	}
+	var mw *MainWindow
+	fyne.DoAndWait(func() {
+		mw = NewMainWindow(a, api.NewClient(url, key, secret), cfg, nil, nil)
+	})
+	t.Cleanup(func() {
+		fyne.DoAndWait(mw.Window.Close)
+		a.Quit()
+	})
+	return mw
+}
+
+// waitFor checks cond on the UI goroutine until it holds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var ok bool
+		fyne.DoAndWait(func() { ok = cond() })
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPollingFindsNewChangesWithoutStream(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	key, secret := srv.RegisterInstance("test")
+	m := srv.AddMonitor(key, api.Monitor{Name: "Example", URL: "https://example.com", FrequencySeconds: 60, Active: true})
+	srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+
+	mw := newTestWindow(t, srv, key, secret, false)
+	waitFor(t, "the history to be looked up", func() bool {
+		st := mw.stats[m.ID]
+		return st != nil && st.changesKnown && !mw.live.streaming
+	})
+
+	c := srv.AddChange(api.ChangeEvent{MonitorID: m.ID})
+	fyne.DoAndWait(mw.sync)
+	waitFor(t, "the new change", func() bool { return len(mw.tray.unread) > 0 })
+
+	// Polling again reports nothing twice.
+	fyne.DoAndWait(mw.sync)
+	fyne.DoAndWait(mw.sync)
+	waitFor(t, "the polls to finish", func() bool { return mw.status.pending == 0 })
+	fyne.DoAndWait(func() {
+		if len(mw.tray.unread) != 1 || mw.tray.unread[0].ID != c.ID {
+			t.Errorf("unread = %+v, want only change %d", mw.tray.unread, c.ID)
+		}
+		if st := mw.stats[m.ID]; st.changes != 2 || !st.lastChange.Equal(c.CreatedAt) {
+			t.Errorf("stats = %+v, want 2 changes, the last at %v", st, c.CreatedAt)
+		}
+	})
+}